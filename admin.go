@@ -0,0 +1,127 @@
+package urlshort
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// AdminHandler returns an http.Handler exposing a JSON API to
+// manage the redirects of store:
+//
+//	GET    /admin/links          list all links as {"path": "url", ...}
+//	PUT    /admin/links/{path}   set path to the url in the {"url": "..."} body
+//	DELETE /admin/links/{path}   remove path
+//
+// The path is given without its leading slash, so the link for
+// "/github" is managed at /admin/links/github. Changes are live
+// immediately. Every request must carry the header
+// "Authorization: Bearer <token>"; others get
+// http.StatusUnauthorized. A PUT of a url not on one of the
+// store's AllowedHosts gets http.StatusBadRequest.
+//
+// The listing carries an ETag computed over the links, so
+// clients polling it can send If-None-Match and get
+// http.StatusNotModified until the links change.
+//
+// An error is returned if token is empty or only whitespace,
+// as a bare "Bearer " header would then be accepted.
+func AdminHandler(store *DynamicHandler, token string) (http.Handler, error) {
+	if strings.TrimSpace(token) == "" {
+		return nil, errors.New("urlshort: empty admin token")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/links", func(w http.ResponseWriter, r *http.Request) {
+		writeJSONWithETag(w, r, store.Links())
+	})
+	mux.HandleFunc("PUT /admin/links/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Url string `json:"url"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&body); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+			return
+		}
+		if strings.TrimSpace(body.Url) == "" {
+			writeJSONError(w, http.StatusBadRequest, "url is required")
+			return
+		}
+		if !store.allowed(body.Url) {
+			writeJSONError(w, http.StatusBadRequest, "url host is not allowed")
+			return
+		}
+		path := "/" + r.PathValue("path")
+		store.Set(path, body.Url)
+		writeJSON(w, http.StatusOK, map[string]string{"path": path, "url": body.Url})
+	})
+	mux.HandleFunc("DELETE /admin/links/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		path := "/" + r.PathValue("path")
+		store.Delete(path)
+		writeJSON(w, http.StatusOK, map[string]string{"path": path})
+	})
+
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	}), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONWithETag writes v as JSON along with an ETag
+// derived from it, or just http.StatusNotModified if the
+// request's If-None-Match already names that ETag. v must
+// encode the same way each time it holds the same data, as
+// maps do.
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	body = append(body, '\n')
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// etagMatches reports whether the If-None-Match header value
+// ifNoneMatch names etag, comparing weakly as RFC 9110
+// requires for If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,149 @@
+package urlshort
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testAdminToken = "s3cret"
+
+// newTestAdmin returns an AdminHandler managing a new empty
+// DynamicHandler, along with that handler.
+func newTestAdmin(t *testing.T) (http.Handler, *DynamicHandler) {
+	t.Helper()
+	store := NewDynamicHandler(http.NotFoundHandler())
+	h, err := AdminHandler(store, testAdminToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h, store
+}
+
+// adminDo serves a request to h carrying token, if not empty,
+// as a bearer token.
+func adminDo(h http.Handler, method, target, body, token string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestAdminHandler(t *testing.T) {
+	h, store := newTestAdmin(t)
+
+	rec := adminDo(h, http.MethodPut, "/admin/links/gh/x", `{"url": "https://example.com/gh"}`, testAdminToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT: got code %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if got := get(store, "/gh/x").Header().Get("Location"); got != "https://example.com/gh" {
+		t.Errorf("/gh/x: got Location %q after PUT, want https://example.com/gh", got)
+	}
+
+	rec = adminDo(h, http.MethodGet, "/admin/links", "", testAdminToken)
+	var links map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &links); err != nil {
+		t.Fatalf("GET: %v: %s", err, rec.Body)
+	}
+	if want := map[string]string{"/gh/x": "https://example.com/gh"}; !maps.Equal(links, want) {
+		t.Errorf("GET: got links %v, want %v", links, want)
+	}
+
+	if rec := adminDo(h, http.MethodDelete, "/admin/links/gh/x", "", testAdminToken); rec.Code != http.StatusOK {
+		t.Errorf("DELETE: got code %d, want 200", rec.Code)
+	}
+	if rec := get(store, "/gh/x"); rec.Code != http.StatusNotFound {
+		t.Errorf("/gh/x: got code %d after DELETE, want fallback", rec.Code)
+	}
+}
+
+func TestAdminHandlerBadRequest(t *testing.T) {
+	h, _ := newTestAdmin(t)
+	for _, body := range []string{"", "not json", `{"url": ""}`, `{"url": "  "}`} {
+		if rec := adminDo(h, http.MethodPut, "/admin/links/a", body, testAdminToken); rec.Code != http.StatusBadRequest {
+			t.Errorf("PUT %q: got code %d, want 400", body, rec.Code)
+		}
+	}
+}
+
+func TestAdminHandlerUnauthorized(t *testing.T) {
+	h, store := newTestAdmin(t)
+	for _, token := range []string{"", "wrong", testAdminToken + "x"} {
+		rec := adminDo(h, http.MethodPut, "/admin/links/a", `{"url": "https://example.com/"}`, token)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("token %q: got code %d, want 401", token, rec.Code)
+		}
+		if rec.Header().Get("WWW-Authenticate") != "Bearer" {
+			t.Errorf("token %q: no WWW-Authenticate challenge", token)
+		}
+	}
+	if len(store.Links()) != 0 {
+		t.Errorf("unauthorized PUT changed the links: %v", store.Links())
+	}
+}
+
+func TestAdminHandlerEmptyToken(t *testing.T) {
+	store := NewDynamicHandler(http.NotFoundHandler())
+	for _, token := range []string{"", " \t"} {
+		if _, err := AdminHandler(store, token); err == nil {
+			t.Errorf("token %q: accepted", token)
+		}
+	}
+}
+
+func TestAdminHandlerETag(t *testing.T) {
+	h, store := newTestAdmin(t)
+	store.Set("/a", "https://example.com/a")
+	list := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/admin/links", nil)
+		r.Header.Set("Authorization", "Bearer "+testAdminToken)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec
+	}
+
+	rec := list("")
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" {
+		t.Fatalf("got code %d with ETag %q, want 200 with one", rec.Code, etag)
+	}
+	for _, inm := range []string{etag, `"other", W/` + etag, "*"} {
+		if rec := list(inm); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: got code %d with %d bytes, want an empty 304", inm, rec.Code, rec.Body.Len())
+		}
+	}
+	if rec := list(`"other"`); rec.Code != http.StatusOK {
+		t.Errorf("non-matching If-None-Match: got code %d, want 200", rec.Code)
+	}
+
+	store.Set("/b", "https://example.com/b")
+	rec = list(etag)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Errorf("after a change: got code %d with ETag %q, want 200 with a new one", rec.Code, rec.Header().Get("ETag"))
+	}
+}
+
+func TestAdminHandlerAllowedHosts(t *testing.T) {
+	h, store := newTestAdmin(t)
+	store.AllowedHosts = []string{"example.com"}
+
+	if rec := adminDo(h, http.MethodPut, "/admin/links/a", `{"url": "https://example.com/a"}`, testAdminToken); rec.Code != http.StatusOK {
+		t.Errorf("allowed host: got code %d, want 200", rec.Code)
+	}
+	rec := adminDo(h, http.MethodPut, "/admin/links/evil", `{"url": "https://evil.example/"}`, testAdminToken)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("disallowed host: got code %d, want 400", rec.Code)
+	}
+	if _, ok := store.Links()["/evil"]; ok {
+		t.Errorf("disallowed host was stored")
+	}
+}
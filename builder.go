@@ -0,0 +1,72 @@
+package urlshort
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Builder accumulates redirects programmatically and builds a
+// handler from them. The zero value is ready to use.
+//
+//	h, err := new(urlshort.Builder).
+//		Add("/gh", "https://github.com").
+//		AddPermanent("/old", "https://example.com/new").
+//		Build(mux)
+type Builder struct {
+	entries map[string]entry
+	errs    []error
+}
+
+// Add registers a temporary (302) redirect from path to url.
+// As in the config formats, a path missing its leading slash
+// gets one.
+func (b *Builder) Add(path, url string) *Builder {
+	return b.add(path, url, http.StatusFound)
+}
+
+// AddPermanent registers a permanent (301) redirect from path
+// to url.
+func (b *Builder) AddPermanent(path, url string) *Builder {
+	return b.add(path, url, http.StatusMovedPermanently)
+}
+
+func (b *Builder) add(path, url string, status int) *Builder {
+	switch {
+	case path == "":
+		b.errs = append(b.errs, fmt.Errorf("urlshort: empty path for url %q", url))
+		return b
+	case strings.TrimSpace(url) == "":
+		b.errs = append(b.errs, fmt.Errorf("urlshort: path %q has an empty url", path))
+		return b
+	}
+	path = normalizePath(path)
+	if _, ok := b.entries[path]; ok {
+		b.errs = append(b.errs, fmt.Errorf("urlshort: duplicate path %q", path))
+		return b
+	}
+
+	if b.entries == nil {
+		b.entries = make(map[string]entry)
+	}
+	b.entries[path] = entry{url: url, status: status}
+	return b
+}
+
+// Build returns an http.HandlerFunc (which also implements
+// http.Handler) serving the accumulated redirects, calling
+// fallback for any other path. It returns every problem found
+// by Add and AddPermanent, such as empty or duplicate paths,
+// instead of a handler.
+func (b *Builder) Build(fallback http.Handler) (http.HandlerFunc, error) {
+	if err := errors.Join(b.errs...); err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]entry, len(b.entries))
+	for path, e := range b.entries {
+		entries[path] = e
+	}
+	return entryHandler(entries, ConfigOptions{}, fallback), nil
+}
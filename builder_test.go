@@ -0,0 +1,63 @@
+package urlshort
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	h, err := new(Builder).
+		Add("/gh", "https://github.com").
+		AddPermanent("/old", "https://example.com/new").
+		Add("docs", "https://example.com/docs").
+		Build(http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		target string
+		code   int
+		want   string
+	}{
+		{"/gh", http.StatusFound, "https://github.com"},
+		{"/old", http.StatusMovedPermanently, "https://example.com/new"},
+		{"/docs", http.StatusFound, "https://example.com/docs"},
+		{"/other", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rec := get(h, tt.target)
+		if rec.Code != tt.code || rec.Header().Get("Location") != tt.want {
+			t.Errorf("%s: got %d %q, want %d %q", tt.target, rec.Code, rec.Header().Get("Location"), tt.code, tt.want)
+		}
+	}
+}
+
+func TestBuilderZeroValue(t *testing.T) {
+	var b Builder
+	h, err := b.Build(http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := get(h, "/a"); rec.Code != http.StatusNotFound {
+		t.Errorf("got code %d, want fallback", rec.Code)
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	_, err := new(Builder).
+		Add("/a", "https://example.com/1").
+		Add("a", "https://example.com/2").
+		Add("", "https://example.com/3").
+		AddPermanent("/b", " ").
+		Build(http.NotFoundHandler())
+	if err == nil {
+		t.Fatal("no error")
+	}
+	for _, want := range []string{`duplicate path "/a"`, "empty path", `"/b" has an empty url`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %s:\n%v", want, err)
+		}
+	}
+}
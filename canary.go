@@ -0,0 +1,62 @@
+package urlshort
+
+import (
+	"hash/fnv"
+	"net/http"
+)
+
+// Canary sends a share of the traffic for a path to a new
+// destination. Percent is the share in the range 0 to 100.
+type Canary struct {
+	Url     string
+	Percent float64
+}
+
+// CanaryHandler works like MapHandler, but paths that have an
+// entry in canaries send Percent of their requests to the
+// canary Url instead. Requests are assigned by a stable hash of
+// the string key returns for them, so the same user keeps
+// getting the same destination. If key is nil, the client IP
+// is used.
+//
+// Paths without a canary are redirected normally.
+func CanaryHandler(pathsToUrls map[string]string, canaries map[string]Canary, key func(*http.Request) string, fallback http.Handler) http.HandlerFunc {
+	if key == nil {
+		key = func(r *http.Request) string { return clientIP(r, false) }
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		dest, ok := pathsToUrls[path]
+		if !ok {
+			fallback.ServeHTTP(w, r)
+			return
+		}
+		if c, ok := canaries[path]; ok && inCanary(path, key(r), c.Percent) {
+			dest = c.Url
+		}
+		http.Redirect(w, r, dest, http.StatusFound)
+	}
+}
+
+// CanaryCookie returns a key function for CanaryHandler that
+// uses the value of the named cookie, falling back to the
+// client IP for requests without it.
+func CanaryCookie(name string) func(*http.Request) string {
+	return func(r *http.Request) string {
+		if c, err := r.Cookie(name); err == nil && c.Value != "" {
+			return c.Value
+		}
+		return clientIP(r, false)
+	}
+}
+
+// inCanary reports whether key falls within percent of the
+// traffic for path. The path is part of the hash so that each
+// path splits its users independently.
+func inCanary(path, key string, percent float64) bool {
+	h := fnv.New32a()
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	return float64(h.Sum32()%10000) < percent*100
+}
@@ -0,0 +1,77 @@
+package urlshort
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// canaryShare returns the share of 1000 users that CanaryHandler
+// with the given percent sends to the canary, failing the test
+// if any user gets different destinations for two requests.
+func canaryShare(t *testing.T, percent float64) float64 {
+	t.Helper()
+	var user string
+	h := CanaryHandler(
+		map[string]string{"/a": "https://example.com/old"},
+		map[string]Canary{"/a": {Url: "https://example.com/new", Percent: percent}},
+		func(*http.Request) string { return user },
+		http.NotFoundHandler(),
+	)
+	n := 0
+	for i := range 1000 {
+		user = fmt.Sprint("user", i)
+		first := get(h, "/a").Header().Get("Location")
+		if again := get(h, "/a").Header().Get("Location"); again != first {
+			t.Fatalf("%s: got %q, then %q", user, first, again)
+		}
+		if first == "https://example.com/new" {
+			n++
+		}
+	}
+	return float64(n) / 1000
+}
+
+func TestCanaryHandler(t *testing.T) {
+	tests := []struct {
+		percent  float64
+		min, max float64
+	}{
+		{0, 0, 0},
+		{10, 0.07, 0.13},
+		{50, 0.45, 0.55},
+		{100, 1, 1},
+	}
+	for _, tt := range tests {
+		if got := canaryShare(t, tt.percent); got < tt.min || got > tt.max {
+			t.Errorf("%v%%: got share %v, want between %v and %v", tt.percent, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestCanaryHandlerOtherPaths(t *testing.T) {
+	h := CanaryHandler(
+		map[string]string{"/b": "https://example.com/b"},
+		map[string]Canary{"/a": {Url: "https://example.com/new", Percent: 100}},
+		nil, http.NotFoundHandler(),
+	)
+	if got := get(h, "/b").Header().Get("Location"); got != "https://example.com/b" {
+		t.Errorf("/b: got Location %q, want https://example.com/b", got)
+	}
+	if rec := get(h, "/a"); rec.Code != http.StatusNotFound {
+		t.Errorf("/a: got code %d for a canary without a mapped path, want fallback", rec.Code)
+	}
+}
+
+func TestCanaryCookie(t *testing.T) {
+	key := CanaryCookie("uid")
+	r := httptest.NewRequest(http.MethodGet, "/a", nil)
+	if got := key(r); got != "192.0.2.1" {
+		t.Errorf("no cookie: got key %q, want the client IP", got)
+	}
+	r.AddCookie(&http.Cookie{Name: "uid", Value: "u42"})
+	if got := key(r); got != "u42" {
+		t.Errorf("with cookie: got key %q, want u42", got)
+	}
+}
@@ -0,0 +1,53 @@
+package urlshort
+
+import (
+	"context"
+	"net/http"
+)
+
+// Fallthrough is a sentinel http.Handler meant to be used as
+// the fallback of handlers passed to Chain. When called within
+// a Chain, it tells the chain to try the next handler. Outside
+// of a Chain it responds with http.NotFound.
+var Fallthrough http.Handler = http.HandlerFunc(fallthroughHandler)
+
+type fallthroughKey struct{}
+
+func fallthroughHandler(w http.ResponseWriter, r *http.Request) {
+	missed, ok := r.Context().Value(fallthroughKey{}).(*bool)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	*missed = true
+}
+
+// Chain returns an http.Handler that tries each of handlers in
+// order. A handler is considered to have missed when it calls
+// Fallthrough, which should be its fallback; the next handler
+// is then tried with the same request. A handler that does
+// anything other than calling Fallthrough ends the chain. If
+// every handler misses, the response is http.NotFound, so a
+// real fallback is usually passed as the last handler.
+//
+// For example, to try a YAML source before some hardcoded
+// entries and finally a mux:
+//
+//	yamlHandler, err := YAMLHandler(yml, Fallthrough)
+//	...
+//	h := Chain(yamlHandler, MapHandler(hardcoded, Fallthrough), mux)
+func Chain(handlers ...http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		missed := new(bool)
+		r = r.WithContext(context.WithValue(r.Context(), fallthroughKey{}, missed))
+		for _, h := range handlers {
+			*missed = false
+			h.ServeHTTP(w, r)
+			if !*missed {
+				return
+			}
+		}
+
+		http.NotFound(w, r)
+	})
+}
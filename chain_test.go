@@ -0,0 +1,43 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	first := MapHandler(map[string]string{"/a": "https://example.com/first"}, Fallthrough)
+	second := MapHandler(map[string]string{
+		"/a": "https://example.com/shadowed",
+		"/b": "https://example.com/second",
+	}, Fallthrough)
+	h := Chain(first, second, NotFoundFallback("last"))
+
+	tests := []struct {
+		target, want string
+	}{
+		{"/a", "https://example.com/first"},
+		{"/b", "https://example.com/second"},
+	}
+	for _, tt := range tests {
+		if got := get(h, tt.target).Header().Get("Location"); got != tt.want {
+			t.Errorf("%s: got Location %q, want %q", tt.target, got, tt.want)
+		}
+	}
+	if rec := get(h, "/c"); rec.Code != http.StatusNotFound || rec.Body.String() != "last" {
+		t.Errorf("/c: got %d %q, want the last handler", rec.Code, rec.Body)
+	}
+}
+
+func TestChainAllMiss(t *testing.T) {
+	h := Chain(MapHandler(map[string]string{"/a": "https://example.com/"}, Fallthrough))
+	if rec := get(h, "/b"); rec.Code != http.StatusNotFound {
+		t.Errorf("got code %d, want 404", rec.Code)
+	}
+}
+
+func TestFallthroughOutsideChain(t *testing.T) {
+	if rec := get(Fallthrough, "/a"); rec.Code != http.StatusNotFound {
+		t.Errorf("got code %d, want 404", rec.Code)
+	}
+}
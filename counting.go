@@ -0,0 +1,57 @@
+package urlshort
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// CountingHandler is an http.Handler that redirects like
+// MapHandler while counting how many times each path was
+// hit. Requests passed to the fallback are counted under the
+// empty string. It is safe for concurrent use.
+type CountingHandler struct {
+	pathsToUrls map[string]string
+	counts      map[string]*atomic.Uint64
+	fallback    http.Handler
+}
+
+// NewCountingHandler returns a CountingHandler serving the
+// paths in pathsToUrls. The map must not be modified
+// afterwards.
+func NewCountingHandler(pathsToUrls map[string]string, fallback http.Handler) *CountingHandler {
+	counts := make(map[string]*atomic.Uint64, len(pathsToUrls)+1)
+	for path := range pathsToUrls {
+		counts[path] = new(atomic.Uint64)
+	}
+	counts[""] = new(atomic.Uint64)
+
+	return &CountingHandler{
+		pathsToUrls: pathsToUrls,
+		counts:      counts,
+		fallback:    fallback,
+	}
+}
+
+// ServeHTTP redirects to the URL mapped to the request path,
+// or calls the fallback if there is none.
+func (h *CountingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	if dest, ok := h.pathsToUrls[path]; ok {
+		h.counts[path].Add(1)
+		http.Redirect(w, r, dest, http.StatusFound)
+		return
+	}
+
+	h.counts[""].Add(1)
+	h.fallback.ServeHTTP(w, r)
+}
+
+// Counts returns the current number of hits for every path,
+// including the fallback under the empty string.
+func (h *CountingHandler) Counts() map[string]uint64 {
+	counts := make(map[string]uint64, len(h.counts))
+	for path, n := range h.counts {
+		counts[path] = n.Load()
+	}
+	return counts
+}
@@ -0,0 +1,40 @@
+package urlshort
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestCountingHandler(t *testing.T) {
+	h := NewCountingHandler(map[string]string{
+		"/a": "https://example.com/a",
+		"/b": "https://example.com/b",
+	}, http.NotFoundHandler())
+
+	var wg sync.WaitGroup
+	for i := range 60 {
+		wg.Go(func() {
+			switch i % 3 {
+			case 0:
+				get(h, "/a")
+			case 1:
+				get(h, "/b")
+			default:
+				get(h, "/missing")
+			}
+		})
+	}
+	wg.Wait()
+
+	want := map[string]uint64{"/a": 20, "/b": 20, "": 20}
+	got := h.Counts()
+	if len(got) != len(want) {
+		t.Errorf("got counts %v, want %v", got, want)
+	}
+	for path, n := range want {
+		if got[path] != n {
+			t.Errorf("%q: got %d hits, want %d", path, got[path], n)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package urlshort
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DynamicHandler is an http.Handler that redirects paths to
+// URLs like MapHandler, but whose mapping can be changed while
+// it is serving. It is safe for concurrent use.
+type DynamicHandler struct {
+	// Now returns the current time, against which TTLs are
+	// set and checked. Nil means time.Now. It must not be
+	// changed while h is in use.
+	Now func() time.Time
+
+	// AllowedHosts, if not empty, restricts redirects to
+	// destinations on these hosts, as Options.AllowedHosts
+	// does for MapHandlerWithOptions; requests for paths mapped
+	// elsewhere are passed to the fallback. It must not be
+	// changed while h is in use.
+	AllowedHosts []string
+
+	mu       sync.RWMutex
+	links    map[string]dynamicLink
+	fallback http.Handler
+}
+
+type dynamicLink struct {
+	url string
+
+	// expires is when the link stops being served. The zero
+	// value means never.
+	expires time.Time
+}
+
+func (l dynamicLink) expired(t time.Time) bool {
+	return !l.expires.IsZero() && !t.Before(l.expires)
+}
+
+// NewDynamicHandler returns an empty DynamicHandler. Paths
+// without a mapping are passed to the fallback http.Handler.
+func NewDynamicHandler(fallback http.Handler) *DynamicHandler {
+	return &DynamicHandler{
+		links:    make(map[string]dynamicLink),
+		fallback: fallback,
+	}
+}
+
+// Set maps path to url, replacing any existing mapping.
+func (h *DynamicHandler) Set(path, url string) {
+	h.set(path, dynamicLink{url: url})
+}
+
+// SetWithTTL maps path to url for ttl, replacing any existing
+// mapping. Once ttl has passed, requests for path are passed
+// to the fallback. Expired mappings are only removed by Sweep,
+// or by a sweeper started with StartSweeper.
+func (h *DynamicHandler) SetWithTTL(path, url string, ttl time.Duration) {
+	h.set(path, dynamicLink{url: url, expires: h.now().Add(ttl)})
+}
+
+func (h *DynamicHandler) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+	return time.Now()
+}
+
+func (h *DynamicHandler) set(path string, link dynamicLink) {
+	h.mu.Lock()
+	h.links[path] = link
+	h.mu.Unlock()
+}
+
+// Delete removes the mapping for path, if any.
+func (h *DynamicHandler) Delete(path string) {
+	h.mu.Lock()
+	delete(h.links, path)
+	h.mu.Unlock()
+}
+
+// Sweep removes every mapping whose TTL has passed.
+func (h *DynamicHandler) Sweep() {
+	t := h.now()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for path, link := range h.links {
+		if link.expired(t) {
+			delete(h.links, path)
+		}
+	}
+}
+
+// StartSweeper starts a goroutine calling Sweep every
+// interval. The returned function stops it and waits for it to
+// exit; calling it more than once is harmless. It panics if
+// interval is not positive.
+func (h *DynamicHandler) StartSweeper(interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		panic("urlshort: non-positive sweep interval: " + interval.String())
+	}
+	ticker := time.NewTicker(interval)
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				h.Sweep()
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(quit) })
+		<-done
+	}
+}
+
+// Links returns a copy of the current mapping of paths to
+// urls, leaving out mappings whose TTL has passed.
+func (h *DynamicHandler) Links() map[string]string {
+	t := h.now()
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	links := make(map[string]string, len(h.links))
+	for path, link := range h.links {
+		if !link.expired(t) {
+			links[path] = link.url
+		}
+	}
+	return links
+}
+
+// Paths returns the currently mapped paths in sorted order.
+func (h *DynamicHandler) Paths() []string {
+	return sortedKeys(h.Links())
+}
+
+// PathsPage returns the currently mapped paths starting with
+// prefix, in sorted order, skipping the first offset and
+// returning at most limit of them, along with the total number
+// of paths starting with prefix. A limit of zero or less means
+// no limit. An offset past the end gives an empty page.
+func (h *DynamicHandler) PathsPage(prefix string, offset, limit int) ([]string, int) {
+	return pagePaths(sortedKeys(h.Links()), prefix, offset, limit)
+}
+
+// ServeHTTP redirects to the URL mapped to the request path,
+// or calls the fallback if there is none.
+func (h *DynamicHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if url, ok := h.lookup(r.URL.Path); ok && h.allowed(url) {
+		http.Redirect(w, r, url, http.StatusFound)
+		return
+	}
+
+	h.fallback.ServeHTTP(w, r)
+}
+
+// lookup returns the url mapped to path, if its TTL has not
+// passed.
+func (h *DynamicHandler) lookup(path string) (string, bool) {
+	h.mu.RLock()
+	link, ok := h.links[path]
+	h.mu.RUnlock()
+	if !ok || link.expired(h.now()) {
+		return "", false
+	}
+	return link.url, true
+}
+
+// allowed reports whether url is on one of h.AllowedHosts, or
+// whether there is no such restriction.
+func (h *DynamicHandler) allowed(url string) bool {
+	return len(h.AllowedHosts) == 0 || hostAllowed(url, h.AllowedHosts)
+}
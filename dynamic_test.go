@@ -0,0 +1,169 @@
+package urlshort
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDynamicHandler(t *testing.T) {
+	h := NewDynamicHandler(http.NotFoundHandler())
+	if rec := get(h, "/a"); rec.Code != http.StatusNotFound {
+		t.Fatalf("empty handler: got code %d, want fallback", rec.Code)
+	}
+
+	h.Set("/a", "https://example.com/1")
+	h.Set("/a", "https://example.com/2")
+	if got := get(h, "/a").Header().Get("Location"); got != "https://example.com/2" {
+		t.Errorf("after replacing: got Location %q", got)
+	}
+
+	h.Delete("/a")
+	if rec := get(h, "/a"); rec.Code != http.StatusNotFound {
+		t.Errorf("after Delete: got code %d, want fallback", rec.Code)
+	}
+	h.Delete("/missing")
+}
+
+func TestDynamicHandlerConcurrent(t *testing.T) {
+	h := NewDynamicHandler(http.NotFoundHandler())
+	h.Set("/stable", "https://example.com/stable")
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 200 {
+				if rec := get(h, "/stable"); rec.Code != http.StatusFound {
+					t.Errorf("/stable: got code %d", rec.Code)
+					return
+				}
+				get(h, "/churn")
+			}
+		})
+		wg.Go(func() {
+			for range 200 {
+				h.Set("/churn", "https://example.com/churn")
+				h.Delete("/churn")
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestDynamicHandlerPaths(t *testing.T) {
+	h := NewDynamicHandler(http.NotFoundHandler())
+	h.Set("/z", "https://example.com/z")
+	h.Set("/y", "https://example.com/y")
+	h.Set("/x", "https://example.com/x")
+	h.Delete("/x")
+	if got, want := h.Paths(), []string{"/y", "/z"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// storedLinks returns the number of links h holds, including
+// expired ones not yet swept.
+func storedLinks(h *DynamicHandler) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.links)
+}
+
+func TestDynamicHandlerTTL(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	h := NewDynamicHandler(http.NotFoundHandler())
+	h.Now = func() time.Time { return now }
+	h.SetWithTTL("/temp", "https://example.com/temp", time.Minute)
+	h.Set("/perm", "https://example.com/perm")
+
+	if rec := get(h, "/temp"); rec.Code != http.StatusFound {
+		t.Errorf("/temp before its TTL: got code %d, want 302", rec.Code)
+	}
+
+	now = start.Add(time.Minute)
+	if rec := get(h, "/temp"); rec.Code != http.StatusNotFound {
+		t.Errorf("/temp after its TTL: got code %d, want fallback", rec.Code)
+	}
+	if got, want := h.Paths(), []string{"/perm"}; !slices.Equal(got, want) {
+		t.Errorf("Paths after the TTL: got %v, want %v", got, want)
+	}
+	if n := storedLinks(h); n != 2 {
+		t.Errorf("got %d stored links before Sweep, want 2", n)
+	}
+
+	h.Sweep()
+	if n := storedLinks(h); n != 1 {
+		t.Errorf("got %d stored links after Sweep, want 1", n)
+	}
+	if rec := get(h, "/perm"); rec.Code != http.StatusFound {
+		t.Errorf("/perm after Sweep: got code %d, want 302", rec.Code)
+	}
+}
+
+func TestDynamicHandlerStartSweeper(t *testing.T) {
+	h := NewDynamicHandler(http.NotFoundHandler())
+	h.SetWithTTL("/temp", "https://example.com/temp", time.Nanosecond)
+	stop := h.StartSweeper(time.Millisecond)
+	defer stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for storedLinks(h) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expired link never swept")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+	stop()
+}
+
+func TestDynamicHandlerStartSweeperInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		func() {
+			defer func() {
+				want := "urlshort: non-positive sweep interval: " + interval.String()
+				if r := recover(); r != want {
+					t.Errorf("%v: got panic %v, want %q", interval, r, want)
+				}
+			}()
+			NewDynamicHandler(http.NotFoundHandler()).StartSweeper(interval)
+		}()
+	}
+}
+
+func TestDynamicHandlerPathsPage(t *testing.T) {
+	h := NewDynamicHandler(http.NotFoundHandler())
+	h.Set("/x/1", "https://example.com/1")
+	h.Set("/x/2", "https://example.com/2")
+	h.Set("/y", "https://example.com/y")
+	page, total := h.PathsPage("/x/", 0, 1)
+	if !slices.Equal(page, []string{"/x/1"}) || total != 2 {
+		t.Errorf("got %v of %d, want [/x/1] of 2", page, total)
+	}
+}
+
+func TestDynamicHandlerAllowedHosts(t *testing.T) {
+	h := NewDynamicHandler(http.NotFoundHandler())
+	h.AllowedHosts = []string{"example.com", "*.example.org"}
+	h.Set("/a", "https://example.com/a")
+	h.Set("/b", "https://go.example.org/b")
+	h.Set("/rel", "/a")
+	h.Set("/evil", "https://evil.example/")
+
+	for path, want := range map[string]string{
+		"/a":    "https://example.com/a",
+		"/b":    "https://go.example.org/b",
+		"/rel":  "/a",
+		"/evil": "",
+	} {
+		if got := get(h, path).Header().Get("Location"); got != want {
+			t.Errorf("%s: got Location %q, want %q", path, got, want)
+		}
+	}
+	if rec := get(h, "/evil"); rec.Code != http.StatusNotFound {
+		t.Errorf("/evil: got code %d, want fallback", rec.Code)
+	}
+}
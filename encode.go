@@ -0,0 +1,64 @@
+package urlshort
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// EncodeURLs returns a copy of pathsToUrls with every
+// destination parsed and re-encoded, so that characters such
+// as spaces end up percent-encoded and the Location header
+// sent for it is always valid. Destinations that are already
+// encoded correctly are left unchanged; existing escapes are
+// never encoded twice. An error naming the path is returned
+// for a destination that cannot be parsed.
+func EncodeURLs(pathsToUrls map[string]string) (map[string]string, error) {
+	encoded := make(map[string]string, len(pathsToUrls))
+	for _, path := range sortedKeys(pathsToUrls) {
+		dest, err := encodeURL(pathsToUrls[path])
+		if err != nil {
+			return nil, fmt.Errorf("urlshort: path %q has an invalid url: %w", path, err)
+		}
+		encoded[path] = dest
+	}
+	return encoded, nil
+}
+
+func encodeURL(dest string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(dest))
+	if err != nil {
+		return "", err
+	}
+	// url.URL.String escapes the path and fragment as needed,
+	// but writes the raw query back as is.
+	u.RawQuery = escapeInvalid(u.RawQuery)
+	return u.String(), nil
+}
+
+// escapeInvalid percent-encodes the bytes of s that may not
+// appear in a URL, including a '%' that does not start a valid
+// escape, and leaves everything else alone.
+func escapeInvalid(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]) {
+			b.WriteByte(c)
+			continue
+		}
+		if c <= ' ' || c >= 0x7f || c == '%' || strings.IndexByte("\"<>\\^`{|}", c) >= 0 {
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&15])
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
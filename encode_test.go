@@ -0,0 +1,57 @@
+package urlshort
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncodeURLs(t *testing.T) {
+	tests := []struct {
+		dest, want string
+	}{
+		{"https://example.com/a b?q=c d&e=%zz", "https://example.com/a%20b?q=c%20d&e=%25zz"},
+		{"https://example.com/a%20b?q=c%20d&x=%2F#frag%20x", "https://example.com/a%20b?q=c%20d&x=%2F#frag%20x"},
+		{"/docs/some page", "/docs/some%20page"},
+		{"https://example.com/café?n=é", "https://example.com/caf%C3%A9?n=%C3%A9"},
+		{"https://example.com/", "https://example.com/"},
+	}
+	for _, tt := range tests {
+		got, err := EncodeURLs(map[string]string{"/a": tt.dest})
+		if err != nil {
+			t.Errorf("%q: %v", tt.dest, err)
+			continue
+		}
+		if got["/a"] != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.dest, got["/a"], tt.want)
+		}
+		// Encoding must be idempotent.
+		again, err := EncodeURLs(got)
+		if err != nil || again["/a"] != tt.want {
+			t.Errorf("%q encoded twice: got %q, %v", tt.dest, again["/a"], err)
+		}
+	}
+}
+
+func TestEncodeURLsInvalid(t *testing.T) {
+	_, err := EncodeURLs(map[string]string{"/bad": "http://a b.example/"})
+	if err == nil || !strings.Contains(err.Error(), "/bad") {
+		t.Errorf("got error %v, want one naming /bad", err)
+	}
+}
+
+func TestParseOptionsEncodeURLs(t *testing.T) {
+	m, err := ParseJSONWithOptions([]byte(`{"/a": "https://example.com/a b"}`), ParseOptions{EncodeURLs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["/a"] != "https://example.com/a%20b" {
+		t.Errorf("JSON: got %q, want the encoded url", m["/a"])
+	}
+	m, err = ParseYAMLWithOptions([]byte("- path: /a\n  url: https://example.com/a b\n"), ParseOptions{EncodeURLs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["/a"] != "https://example.com/a%20b" {
+		t.Errorf("YAML: got %q, want the encoded url", m["/a"])
+	}
+}
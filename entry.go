@@ -0,0 +1,458 @@
+package urlshort
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ConfigOptions configures YAMLHandlerWithOptions and
+// JSONHandlerWithOptions.
+type ConfigOptions struct {
+	// Now returns the current time, against which entry
+	// expiry is checked on every request. Nil means time.Now.
+	Now func() time.Time
+
+	// Rand returns a random number in [0, 1) used to pick among
+	// the weighted destinations of an entry, such as the
+	// Float64 method of a seeded *rand.Rand. It is called
+	// concurrently by requests, so it must be safe for
+	// concurrent use. Nil means rand.Float64.
+	Rand func() float64
+}
+
+// entry is a single redirect along with the settings that can
+// be given per path in richer config formats such as YAML.
+type entry struct {
+	url     string
+	status  int
+	expires time.Time
+
+	// weighted holds the destinations of an entry with more
+	// than one, with cumulative weights normalized to 1.
+	weighted []weightedUrl
+
+	// methods, if not empty, lists the only HTTP methods the
+	// entry redirects.
+	methods []string
+
+	// query, if not empty, holds query parameter values a
+	// request must carry for the entry to apply. Such entries
+	// are stored as variants of the entry for their path.
+	query map[string]string
+
+	// variants are the entries for the same path that carry
+	// a query, tried in config order before the entry itself.
+	variants []entry
+
+	// agents are alternate destinations for requests from
+	// matching user agents, tried in config order.
+	agents []agentRule
+
+	// gone marks a retired entry, which is answered with
+	// http.StatusGone and goneMessage instead of a redirect.
+	gone        bool
+	goneMessage string
+}
+
+// agentRule sends requests whose User-Agent header matches to
+// url. Exactly one of contains, which is lowercased, and re is
+// set.
+type agentRule struct {
+	contains string
+	re       *regexp.Regexp
+	url      string
+}
+
+func (a agentRule) matches(userAgent string) bool {
+	if a.re != nil {
+		return a.re.MatchString(userAgent)
+	}
+	return strings.Contains(strings.ToLower(userAgent), a.contains)
+}
+
+// entryConfig holds the fields of an entry as written in a
+// config file, independent of the format.
+type entryConfig struct {
+	Path         string
+	Url          string
+	Status       int
+	Expires      string
+	Destinations []weightedUrl
+	Methods      []string
+	Query        map[string]string
+	Alias        string
+	Agents       []agentConfig
+	Gone         bool
+	Message      string
+	Disabled     bool
+}
+
+// agentConfig is an alternate destination for user agents
+// whose User-Agent header contains Contains, ignoring case, or
+// matches the regular expression Regex.
+type agentConfig struct {
+	Contains string
+	Regex    string
+	Url      string
+}
+
+type weightedUrl struct {
+	Url    string
+	Weight float64
+}
+
+// buildEntries validates configs and turns them into entries
+// keyed by normalized path. Later configs for the same path
+// win, except that configs with a query are kept as variants
+// of the entry for their path. Configs with an alias get a
+// copy of the entry of the path they refer to. Disabled
+// configs are validated but left out.
+func buildEntries(configs []entryConfig) (map[string]entry, error) {
+	entries := make(map[string]entry)
+	variants := make(map[string][]entry)
+	aliases := make(map[string]string)
+	for _, cfg := range configs {
+		if cfg.Alias != "" {
+			if cfg.Url != "" || len(cfg.Destinations) > 0 || len(cfg.Query) > 0 {
+				return nil, fmt.Errorf("urlshort: path %q has an alias and a url, destinations or query", cfg.Path)
+			}
+			if !cfg.Disabled {
+				path := normalizePath(cfg.Path)
+				aliases[path] = normalizePath(cfg.Alias)
+				delete(entries, path)
+			}
+			continue
+		}
+		e, err := newEntry(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Disabled {
+			continue
+		}
+		path := normalizePath(cfg.Path)
+		if len(e.query) > 0 {
+			if strings.TrimSpace(e.url) == "" {
+				return nil, fmt.Errorf("urlshort: path %q has an empty url for query %v", cfg.Path, e.query)
+			}
+			variants[path] = append(variants[path], e)
+			continue
+		}
+		entries[path] = e
+		delete(aliases, path)
+	}
+	if err := checkDestinations(entryUrls(entries)); err != nil {
+		return nil, err
+	}
+	for _, path := range sortedKeys(aliases) {
+		if len(variants[path]) > 0 {
+			return nil, fmt.Errorf("urlshort: path %q is an alias and also has entries with a query", path)
+		}
+	}
+	for path, vs := range variants {
+		e := entries[path]
+		e.variants = vs
+		entries[path] = e
+	}
+
+	resolved := make(map[string]entry, len(aliases))
+	for _, path := range sortedKeys(aliases) {
+		e, err := resolveAlias(path, aliases, entries)
+		if err != nil {
+			return nil, err
+		}
+		resolved[path] = e
+	}
+	for path, e := range resolved {
+		entries[path] = e
+	}
+	return entries, nil
+}
+
+// resolveAlias follows the chain of aliases starting at path
+// and returns the entry it ends at. It is an error for the
+// chain to loop or to end at a path without an entry.
+func resolveAlias(path string, aliases map[string]string, entries map[string]entry) (entry, error) {
+	chain := []string{path}
+	for target := aliases[path]; ; target = aliases[target] {
+		if slices.Contains(chain, target) {
+			return entry{}, fmt.Errorf("urlshort: alias cycle: %s -> %s", strings.Join(chain, " -> "), target)
+		}
+		chain = append(chain, target)
+		if _, ok := aliases[target]; ok {
+			continue
+		}
+		e, ok := entries[target]
+		if !ok {
+			return entry{}, fmt.Errorf("urlshort: path %q is an alias of %q, which does not exist", path, target)
+		}
+		return e, nil
+	}
+}
+
+// newEntry builds an entry from its config fields. A zero
+// status defaults to http.StatusFound and an empty expires
+// means the entry never expires.
+func newEntry(cfg entryConfig) (entry, error) {
+	status := cfg.Status
+	if status == 0 {
+		status = http.StatusFound
+	}
+	if !isRedirectStatus(status) {
+		return entry{}, fmt.Errorf("urlshort: path %q has invalid status %d", cfg.Path, status)
+	}
+
+	e := entry{url: cfg.Url, status: status, query: cfg.Query, gone: cfg.Gone, goneMessage: cfg.Message}
+	for _, m := range cfg.Methods {
+		e.methods = append(e.methods, strings.ToUpper(strings.TrimSpace(m)))
+	}
+	if cfg.Expires != "" {
+		t, err := time.Parse(time.RFC3339, cfg.Expires)
+		if err != nil {
+			return entry{}, fmt.Errorf("urlshort: path %q has invalid expires: %w", cfg.Path, err)
+		}
+		e.expires = t
+	}
+	for _, a := range cfg.Agents {
+		rule, err := newAgentRule(cfg.Path, a)
+		if err != nil {
+			return entry{}, err
+		}
+		e.agents = append(e.agents, rule)
+	}
+	if len(cfg.Destinations) > 0 {
+		if cfg.Url != "" {
+			return entry{}, fmt.Errorf("urlshort: path %q has both url and destinations", cfg.Path)
+		}
+		weighted, err := normalizeWeights(cfg.Path, cfg.Destinations)
+		if err != nil {
+			return entry{}, err
+		}
+		e.url = weighted[0].Url
+		if len(weighted) > 1 {
+			e.weighted = weighted
+		}
+	}
+	return e, nil
+}
+
+func newAgentRule(path string, a agentConfig) (agentRule, error) {
+	if strings.TrimSpace(a.Url) == "" {
+		return agentRule{}, fmt.Errorf("urlshort: path %q has a user agent rule with an empty url", path)
+	}
+	if (a.Contains == "") == (a.Regex == "") {
+		return agentRule{}, fmt.Errorf("urlshort: path %q has a user agent rule without exactly one of contains and regex", path)
+	}
+	rule := agentRule{contains: strings.ToLower(a.Contains), url: a.Url}
+	if a.Regex != "" {
+		re, err := regexp.Compile(a.Regex)
+		if err != nil {
+			return agentRule{}, fmt.Errorf("urlshort: path %q has an invalid user agent regex: %w", path, err)
+		}
+		rule.re = re
+	}
+	return rule, nil
+}
+
+// normalizeWeights returns dests with cumulative weights
+// scaled to end at 1. A zero weight counts as 1.
+func normalizeWeights(path string, dests []weightedUrl) ([]weightedUrl, error) {
+	weighted := make([]weightedUrl, len(dests))
+	var total float64
+	for i, d := range dests {
+		if strings.TrimSpace(d.Url) == "" {
+			return nil, fmt.Errorf("urlshort: path %q has a destination with an empty url", path)
+		}
+		if d.Weight < 0 {
+			return nil, fmt.Errorf("urlshort: path %q has a negative weight for %q", path, d.Url)
+		}
+		if math.IsNaN(d.Weight) || math.IsInf(d.Weight, 0) {
+			return nil, fmt.Errorf("urlshort: path %q has a non-finite weight for %q", path, d.Url)
+		}
+		if d.Weight == 0 {
+			d.Weight = 1
+		}
+		total += d.Weight
+		weighted[i] = weightedUrl{Url: d.Url, Weight: total}
+	}
+	for i := range weighted {
+		weighted[i].Weight /= total
+	}
+	return weighted, nil
+}
+
+// expired reports whether e should no longer be served at t.
+func (e entry) expired(t time.Time) bool {
+	return !e.expires.IsZero() && !t.Before(e.expires)
+}
+
+// allows reports whether e redirects requests with method.
+func (e entry) allows(method string) bool {
+	return len(e.methods) == 0 || slices.Contains(e.methods, method)
+}
+
+// matchesQuery reports whether q carries every parameter
+// value e requires. Other parameters in q are ignored.
+func (e entry) matchesQuery(q url.Values) bool {
+	for key, value := range e.query {
+		if !slices.Contains(q[key], value) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkIgnoredQuery returns an error if an entry only applies
+// to requests carrying a query parameter matched by one of the
+// patterns, which are parameter names, or prefixes of them
+// followed by "*" such as "utm_*".
+func checkIgnoredQuery(entries map[string]entry, patterns []string) error {
+	for _, path := range slices.Sorted(maps.Keys(entries)) {
+		for _, v := range entries[path].variants {
+			for _, key := range slices.Sorted(maps.Keys(v.query)) {
+				if queryIgnored(key, patterns) {
+					return fmt.Errorf("urlshort: path %q matches query parameter %q, which is ignored", path, key)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func queryIgnored(key string, patterns []string) bool {
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok && strings.HasPrefix(key, prefix) || p == key {
+			return true
+		}
+	}
+	return false
+}
+
+// applies reports whether e redirects r at time t, ignoring
+// its variants.
+func (e entry) applies(r *http.Request, t time.Time) bool {
+	return (e.url != "" || e.gone) && e.allows(r.Method) && !e.expired(t)
+}
+
+// match returns the entry to redirect r with at time t: the
+// first of the variants of e whose query matches r, or else e
+// itself. It reports false if neither applies.
+func (e entry) match(r *http.Request, t time.Time) (entry, bool) {
+	if len(e.variants) > 0 {
+		q := r.URL.Query()
+		for _, v := range e.variants {
+			if v.matchesQuery(q) && v.applies(r, t) {
+				return v, true
+			}
+		}
+	}
+	return e, e.applies(r, t)
+}
+
+// destination returns the url to redirect r to: that of the
+// first user agent rule matching r, or else one of the
+// weighted destinations if there are several.
+func (e entry) destination(r *http.Request, rnd func() float64) string {
+	if len(e.agents) > 0 {
+		ua := r.UserAgent()
+		for _, a := range e.agents {
+			if a.matches(ua) {
+				return a.url
+			}
+		}
+	}
+	if len(e.weighted) == 0 {
+		return e.url
+	}
+	x := rnd()
+	for _, w := range e.weighted {
+		if x < w.Weight {
+			return w.Url
+		}
+	}
+	return e.weighted[len(e.weighted)-1].Url
+}
+
+// entryHandler works like MapHandler, but redirects each path
+// with the status stored in its entry, prefers variants whose
+// query matches the request, answers gone entries with
+// http.StatusGone, and skips entries that have expired or do
+// not allow the request method. Weighted destinations are
+// picked with opts.Rand, and expiry is checked against
+// opts.Now.
+func entryHandler(entries map[string]entry, opts ConfigOptions, fallback http.Handler) http.HandlerFunc {
+	clock := opts.Now
+	if clock == nil {
+		clock = time.Now
+	}
+	rnd := opts.Rand
+	if rnd == nil {
+		rnd = rand.Float64
+	}
+
+	prepared := make(map[redirectKey]preparedRedirect)
+	prepare := func(e entry) {
+		if p, ok := prepareRedirect(e.url, e.status); ok {
+			prepared[redirectKey{e.url, e.status}] = p
+		}
+	}
+	for _, e := range entries {
+		prepare(e)
+		for _, v := range e.variants {
+			prepare(v)
+		}
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if e, ok := entries[r.URL.Path]; ok {
+			if e, ok := e.match(r, clock()); ok {
+				if e.gone {
+					serveGone(w, e.goneMessage)
+					return
+				}
+				dest := e.destination(r, rnd)
+				if p, ok := prepared[redirectKey{dest, e.status}]; ok {
+					p.serve(w, r, e.status)
+					return
+				}
+				http.Redirect(w, r, dest, e.status)
+				return
+			}
+		}
+
+		fallback.ServeHTTP(w, r)
+	}
+}
+
+// serveGone responds with http.StatusGone and message, or the
+// status text if message is empty.
+func serveGone(w http.ResponseWriter, message string) {
+	if message == "" {
+		message = http.StatusText(http.StatusGone)
+	}
+	http.Error(w, message, http.StatusGone)
+}
+
+// entryUrls returns the plain mapping of paths to urls. For
+// entries with weighted destinations, the first one is used.
+// Variants and gone entries cannot be expressed in a plain
+// mapping and are left out, as are paths that only have
+// variants.
+func entryUrls(entries map[string]entry) map[string]string {
+	pathsToUrls := make(map[string]string, len(entries))
+	for path, e := range entries {
+		if e.gone || e.url == "" && len(e.variants) > 0 {
+			continue
+		}
+		pathsToUrls[path] = e.url
+	}
+	return pathsToUrls
+}
@@ -0,0 +1,500 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mustYAML returns a YAMLHandler for yml falling back to
+// http.NotFoundHandler, failing the test if yml is rejected.
+func mustYAML(t *testing.T, yml string) http.HandlerFunc {
+	t.Helper()
+	h, err := YAMLHandler([]byte(yml), http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestEntryStatus(t *testing.T) {
+	h := mustYAML(t, `
+- path: /moved
+  url: https://example.com/new
+  status: 301
+- path: /temp
+  url: https://example.com/temp
+  status: 307
+- path: /default
+  url: https://example.com/default
+`)
+	for path, want := range map[string]int{"/moved": 301, "/temp": 307, "/default": 302} {
+		rec := get(h, path)
+		if rec.Code != want {
+			t.Errorf("%s: got code %d, want %d", path, rec.Code, want)
+		}
+		if rec.Header().Get("Location") == "" {
+			t.Errorf("%s: no Location", path)
+		}
+	}
+}
+
+func TestEntryStatusInvalid(t *testing.T) {
+	for _, status := range []string{"200", "404", "600"} {
+		yml := "- path: /a\n  url: https://example.com/\n  status: " + status + "\n"
+		if _, err := YAMLHandler([]byte(yml), http.NotFoundHandler()); err == nil {
+			t.Errorf("status %s: accepted", status)
+		}
+	}
+}
+
+func TestEntryExpiry(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	opts := ConfigOptions{Now: func() time.Time { return now }}
+	tests := []struct {
+		name string
+		h    func([]byte, ConfigOptions, http.Handler) (http.HandlerFunc, error)
+		data string
+	}{
+		{"YAML", YAMLHandlerWithOptions, `
+- path: /past
+  url: https://example.com/past
+  expires: 2024-01-01T00:00:00Z
+- path: /future
+  url: https://example.com/future
+  expires: 2025-01-01T00:00:00Z
+- path: /forever
+  url: https://example.com/forever
+`},
+		{"JSON", JSONHandlerWithOptions, `[
+  {"path": "/past", "url": "https://example.com/past", "expires": "2024-01-01T00:00:00Z"},
+  {"path": "/future", "url": "https://example.com/future", "expires": "2025-01-01T00:00:00Z"},
+  {"path": "/forever", "url": "https://example.com/forever"}
+]`},
+	}
+	for _, tt := range tests {
+		h, err := tt.h([]byte(tt.data), opts, http.NotFoundHandler())
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		for path, want := range map[string]int{"/past": 404, "/future": 302, "/forever": 302} {
+			if rec := get(h, path); rec.Code != want {
+				t.Errorf("%s %s: got code %d, want %d", tt.name, path, rec.Code, want)
+			}
+		}
+	}
+}
+
+func TestEntryExpiryIsLive(t *testing.T) {
+	now := time.Date(2024, 12, 31, 23, 59, 0, 0, time.UTC)
+	opts := ConfigOptions{Now: func() time.Time { return now }}
+	h, err := YAMLHandlerWithOptions([]byte("- path: /sale\n  url: https://example.com/sale\n  expires: 2025-01-01T00:00:00Z\n"), opts, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := get(h, "/sale"); rec.Code != http.StatusFound {
+		t.Errorf("before expiry: got code %d, want 302", rec.Code)
+	}
+	now = now.Add(time.Minute)
+	if rec := get(h, "/sale"); rec.Code != http.StatusNotFound {
+		t.Errorf("at expiry: got code %d, want fallback", rec.Code)
+	}
+}
+
+func TestEntryExpiryInvalid(t *testing.T) {
+	if _, err := YAMLHandler([]byte("- path: /a\n  url: https://example.com/\n  expires: tomorrow\n"), http.NotFoundHandler()); err == nil {
+		t.Error("YAML: invalid expiry accepted")
+	}
+	if _, err := JSONHandler([]byte(`[{"path": "/a", "url": "https://example.com/", "expires": "2024-13-01"}]`), http.NotFoundHandler()); err == nil {
+		t.Error("JSON: invalid expiry accepted")
+	}
+}
+
+func TestEntryWeightedDestinations(t *testing.T) {
+	yml := []byte(`
+- path: /p
+  destinations:
+    - url: https://example.com/a
+      weight: 3
+    - url: https://example.com/b
+      weight: 1
+`)
+	tests := []struct {
+		x    float64
+		want string
+	}{
+		{0, "https://example.com/a"},
+		{0.74, "https://example.com/a"},
+		{0.75, "https://example.com/b"},
+		{0.99, "https://example.com/b"},
+	}
+	for _, tt := range tests {
+		x := tt.x
+		h, err := YAMLHandlerWithOptions(yml, ConfigOptions{Rand: func() float64 { return x }}, http.NotFoundHandler())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := get(h, "/p").Header().Get("Location"); got != tt.want {
+			t.Errorf("rand %v: got Location %q, want %q", tt.x, got, tt.want)
+		}
+	}
+}
+
+func TestEntryWeightedDefaultWeight(t *testing.T) {
+	h, err := JSONHandlerWithOptions([]byte(`[{"path": "/p", "destinations": [
+		{"url": "https://example.com/a"},
+		{"url": "https://example.com/b"}
+	]}]`), ConfigOptions{Rand: func() float64 { return 0.5 }}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := get(h, "/p").Header().Get("Location"); got != "https://example.com/b" {
+		t.Errorf("rand 0.5 with equal weights: got Location %q, want https://example.com/b", got)
+	}
+}
+
+func TestEntryWeightedInvalid(t *testing.T) {
+	for _, yml := range []string{
+		"- path: /p\n  destinations:\n    - url: https://example.com/a\n      weight: -1\n",
+		"- path: /p\n  destinations:\n    - url: https://example.com/a\n      weight: .inf\n",
+		"- path: /p\n  destinations:\n    - url: \"\"\n",
+	} {
+		if _, err := YAMLHandler([]byte(yml), http.NotFoundHandler()); err == nil {
+			t.Errorf("%q: accepted", yml)
+		}
+	}
+}
+
+func TestEntryMethods(t *testing.T) {
+	h := mustYAML(t, `
+- path: /api
+  url: https://example.com/api
+  methods: [get, " HEAD "]
+- path: /any
+  url: https://example.com/any
+`)
+	tests := []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{http.MethodGet, "/api", http.StatusFound},
+		{http.MethodHead, "/api", http.StatusFound},
+		{http.MethodPost, "/api", http.StatusNotFound},
+		{http.MethodPost, "/any", http.StatusFound},
+		{http.MethodDelete, "/any", http.StatusFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%s %s: got code %d, want %d", tt.method, tt.path, rec.Code, tt.code)
+		}
+	}
+}
+
+func TestEntryDisabled(t *testing.T) {
+	h := mustYAML(t, `
+- path: /a
+  url: https://example.com/a
+  disabled: true
+- path: /b
+  url: https://example.com/b
+  disabled: false
+- path: /c
+  url: https://example.com/c
+- path: /c
+  url: https://example.com/c2
+  disabled: true
+`)
+	for path, want := range map[string]string{
+		"/a": "",
+		"/b": "https://example.com/b",
+		"/c": "https://example.com/c",
+	} {
+		if got := get(h, path).Header().Get("Location"); got != want {
+			t.Errorf("%s: got Location %q, want %q", path, got, want)
+		}
+	}
+
+	j, err := JSONHandler([]byte(`[{"path": "/a", "url": "https://example.com/a", "disabled": true}]`), http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := get(j, "/a"); rec.Code != http.StatusNotFound {
+		t.Errorf("JSON /a: got code %d, want fallback", rec.Code)
+	}
+}
+
+func TestEntryDisabledIsValidated(t *testing.T) {
+	if _, err := YAMLHandler([]byte("- path: /a\n  url: https://example.com/a\n  status: 600\n  disabled: true\n"), http.NotFoundHandler()); err == nil {
+		t.Error("disabled entry with an invalid status accepted")
+	}
+}
+
+func TestEntryQueryVariants(t *testing.T) {
+	h := mustYAML(t, `
+- path: /search
+  url: https://example.com/help
+  query: {q: foo}
+- path: /search
+  url: https://example.com/search
+- path: /only
+  url: https://example.com/only
+  query: {a: "1", b: "2"}
+`)
+	tests := []struct {
+		target, want string
+	}{
+		{"/search?q=foo", "https://example.com/help"},
+		{"/search?x=1&q=foo", "https://example.com/help"},
+		{"/search?q=bar", "https://example.com/search"},
+		{"/search", "https://example.com/search"},
+		{"/only?b=2&a=1&c=3", "https://example.com/only"},
+		{"/only?a=1", ""},
+		{"/only", ""},
+	}
+	for _, tt := range tests {
+		if got := get(h, tt.target).Header().Get("Location"); got != tt.want {
+			t.Errorf("%s: got Location %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestEntryQueryVariantsParse(t *testing.T) {
+	m, err := ParseYAML([]byte("- path: /only\n  url: https://example.com/only\n  query: {a: '1'}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 0 {
+		t.Errorf("got %v, want query variants left out of the flat map", m)
+	}
+	if _, err := JSONHandler([]byte(`[{"path": "/s", "url": "", "query": {"q": "x"}}]`), http.NotFoundHandler()); err == nil {
+		t.Errorf("query variant with an empty url accepted")
+	}
+}
+
+func TestEntryQueryVariantsNotDuplicates(t *testing.T) {
+	yml := []byte("- path: /s\n  url: https://example.com/a\n  query: {q: x}\n- path: /s\n  url: https://example.com/b\n")
+	if _, err := ParseYAMLWithOptions(yml, ParseOptions{DisallowDuplicates: true}); err != nil {
+		t.Errorf("variant and plain entry reported as duplicates: %v", err)
+	}
+	if errs := validateUpload("yaml", yml); len(errs) != 0 {
+		t.Errorf("upload validation: got %v, want no problems", errs)
+	}
+
+	jsn := []byte(`[
+		{"path": "/s", "url": "https://example.com/a", "query": {"q": "x"}},
+		{"path": "s", "url": "https://example.com/b", "query": {"q": "x"}}
+	]`)
+	_, err := ParseJSONWithOptions(jsn, ParseOptions{DisallowDuplicates: true})
+	if err == nil || !strings.Contains(err.Error(), `"/s?q=x"`) {
+		t.Errorf("got error %v, want one naming /s?q=x", err)
+	}
+}
+
+func TestEntryAlias(t *testing.T) {
+	h := mustYAML(t, `
+- path: /github
+  url: https://github.com/
+  status: 301
+- path: /gh
+  alias: /github
+- path: g
+  alias: gh
+`)
+	for _, path := range []string{"/github", "/gh", "/g"} {
+		rec := get(h, path)
+		if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "https://github.com/" {
+			t.Errorf("%s: got %d %q, want 301 https://github.com/", path, rec.Code, rec.Header().Get("Location"))
+		}
+	}
+
+	m, err := ParseYAML([]byte("- path: /a\n  url: https://example.com/a\n- path: /b\n  alias: /a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["/b"] != "https://example.com/a" {
+		t.Errorf("ParseYAML: got /b %q, want the url of /a", m["/b"])
+	}
+}
+
+func TestEntryAliasInvalid(t *testing.T) {
+	tests := []struct {
+		name, json, want string
+	}{
+		{"cycle", `[{"path": "/a", "alias": "/b"}, {"path": "/b", "alias": "/c"}, {"path": "/c", "alias": "/a"}]`, "cycle"},
+		{"missing target", `[{"path": "/a", "alias": "/missing"}]`, "does not exist"},
+		{"alias and url", `[{"path": "/a", "alias": "/b", "url": "https://example.com/"}, {"path": "/b", "url": "https://example.com/b"}]`, "alias and a url"},
+		{"alias and query variant", `[{"path": "/a", "url": "https://example.com/a"}, {"path": "/gh", "alias": "/a"}, {"path": "/gh", "url": "https://example.com/q", "query": {"q": "x"}}]`, "also has entries with a query"},
+	}
+	for _, tt := range tests {
+		_, err := JSONHandler([]byte(tt.json), http.NotFoundHandler())
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got error %v, want one containing %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestEntryWeightedNonFinite(t *testing.T) {
+	for _, w := range []string{".nan", ".inf", "-.inf"} {
+		yml := "- path: /a\n  destinations: [{url: https://example.com/a, weight: " + w + "}]\n"
+		if _, err := ParseYAML([]byte(yml)); err == nil {
+			t.Errorf("weight %s: accepted", w)
+		}
+	}
+}
+
+func TestEntryUserAgents(t *testing.T) {
+	h := mustYAML(t, `
+- path: /app
+  url: https://example.com/site
+  user_agents:
+    - contains: iphone
+      url: https://example.com/apple
+    - regex: "(?i)android"
+      url: https://example.com/play
+    - contains: mobile
+      url: https://example.com/mobile
+`)
+	tests := []struct {
+		ua, want string
+	}{
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) Mobile", "https://example.com/apple"},
+		{"Mozilla/5.0 (Linux; Android 14) Mobile", "https://example.com/play"},
+		{"Mozilla/5.0 (X11; Linux x86_64) Mobile", "https://example.com/mobile"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64)", "https://example.com/site"},
+		{"", "https://example.com/site"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/app", nil)
+		r.Header.Set("User-Agent", tt.ua)
+		rec := httptest.NewRecorder()
+		h(rec, r)
+		if got := rec.Header().Get("Location"); got != tt.want {
+			t.Errorf("%q: got Location %q, want %q", tt.ua, got, tt.want)
+		}
+	}
+}
+
+func TestEntryUserAgentsInvalid(t *testing.T) {
+	for _, jsn := range []string{
+		`[{"path": "/a", "url": "https://example.com/", "user_agents": [{"regex": "(", "url": "https://example.com/y"}]}]`,
+		`[{"path": "/a", "url": "https://example.com/", "user_agents": [{"url": "https://example.com/y"}]}]`,
+		`[{"path": "/a", "url": "https://example.com/", "user_agents": [{"contains": "a", "regex": "b", "url": "https://example.com/y"}]}]`,
+		`[{"path": "/a", "url": "https://example.com/", "user_agents": [{"contains": "a"}]}]`,
+	} {
+		if _, err := JSONHandler([]byte(jsn), http.NotFoundHandler()); err == nil {
+			t.Errorf("%s: accepted", jsn)
+		}
+	}
+}
+
+func TestEntryGone(t *testing.T) {
+	h := mustYAML(t, `
+- path: /sale
+  gone: true
+  message: This campaign has ended.
+- path: /old
+  gone: true
+- path: /a
+  url: https://example.com/a
+- path: /s
+  alias: /sale
+`)
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/sale", http.StatusGone, "This campaign has ended.\n"},
+		{"/old", http.StatusGone, "Gone\n"},
+		{"/s", http.StatusGone, "This campaign has ended.\n"},
+	}
+	for _, tt := range tests {
+		rec := get(h, tt.path)
+		if rec.Code != tt.code || rec.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, want %d %q", tt.path, rec.Code, rec.Body, tt.code, tt.body)
+		}
+	}
+	if rec := get(h, "/a"); rec.Code != http.StatusFound {
+		t.Errorf("/a: got code %d, want 302", rec.Code)
+	}
+
+	m, err := ParseJSON([]byte(`[{"path": "/x", "gone": true}, {"path": "/a", "url": "https://example.com/a"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m["/x"]; ok || len(m) != 1 {
+		t.Errorf("ParseJSON: got %v, want the gone entry left out", m)
+	}
+}
+
+func TestEntryIgnoreQuery(t *testing.T) {
+	var fallbackQuery string
+	fallback := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fallbackQuery = r.URL.RawQuery
+		http.NotFound(w, r)
+	})
+	h, err := YAMLHandler([]byte(`
+ignore_query: [utm_*, fbclid]
+redirects:
+  - path: /s
+    url: https://example.com/default
+  - path: /s
+    url: https://example.com/help
+    query: {q: help}
+`), fallback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for target, want := range map[string]string{
+		"/s?q=help&utm_source=x&fbclid=1": "https://example.com/help",
+		"/s?utm_source=x&utm_medium=y":    "https://example.com/default",
+		"/s?q=other&fbclid=1":             "https://example.com/default",
+	} {
+		if got := get(h, target).Header().Get("Location"); got != want {
+			t.Errorf("%s: got Location %q, want %q", target, got, want)
+		}
+	}
+	get(h, "/other?utm_source=x")
+	if fallbackQuery != "utm_source=x" {
+		t.Errorf("fallback got query %q, want the ignored parameter kept", fallbackQuery)
+	}
+}
+
+func TestEntryIgnoreQueryInvalid(t *testing.T) {
+	yml := []byte("ignore_query: [utm_*]\nredirects:\n  - path: /a\n    url: https://example.com/a\n    query: {utm_source: x}\n")
+	jsn := []byte(`{"ignore_query": ["fbclid"], "redirects": [{"path": "/a", "url": "https://example.com/a", "query": {"fbclid": "1"}}]}`)
+	checks := []struct {
+		name string
+		err  error
+	}{
+		{"YAMLHandler", second(YAMLHandler(yml, nil))},
+		{"ParseYAML", second(ParseYAML(yml))},
+		{"ParseYAMLWithOptions", second(ParseYAMLWithOptions(yml, ParseOptions{DisallowDuplicates: true}))},
+		{"JSONHandler", second(JSONHandler(jsn, nil))},
+		{"ParseJSON", second(ParseJSON(jsn))},
+	}
+	for _, c := range checks {
+		if c.err == nil || !strings.Contains(c.err.Error(), "ignored") {
+			t.Errorf("%s: got error %v, want one about an ignored parameter", c.name, c.err)
+		}
+	}
+	if errs := validateUpload("yaml", yml); len(errs) != 1 {
+		t.Errorf("validateUpload: got %v, want one error", errs)
+	}
+
+	m, err := ParseJSON([]byte(`{"ignore_query": ["fbclid"], "redirects": [{"path": "/a", "url": "https://example.com/a"}]}`))
+	if err != nil || m["/a"] != "https://example.com/a" {
+		t.Errorf("ParseJSON: got %v, %v", m, err)
+	}
+}
+
+// second returns the error of a call returning a value and an
+// error.
+func second[T any](_ T, err error) error {
+	return err
+}
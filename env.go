@@ -0,0 +1,51 @@
+package urlshort
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// EnvHandler will parse the environment variable named envVar
+// and then return an http.HandlerFunc (which also implements
+// http.Handler) that will attempt to map any paths to their
+// corresponding URL. If the path is not provided in the
+// variable, then the fallback http.Handler will be called
+// instead.
+//
+// The variable is expected to hold comma-separated path=url
+// pairs, for example:
+//
+//	URLSHORT_MAP="/a=https://x.com,/b=https://y.com"
+//
+// Whitespace around each pair is ignored. An unset or empty
+// variable results in an empty mapping. The only errors that
+// can be returned all related to malformed pairs.
+func EnvHandler(envVar string, fallback http.Handler) (http.HandlerFunc, error) {
+	pathsToUrls, err := parseEnv(os.Getenv(envVar))
+	if err != nil {
+		return nil, err
+	}
+
+	return MapHandler(pathsToUrls, fallback), nil
+}
+
+func parseEnv(value string) (map[string]string, error) {
+	pathToUrls := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		path, url, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("urlshort: malformed pair %q, want path=url", pair)
+		}
+		pathToUrls[normalizePath(strings.TrimSpace(path))] = strings.TrimSpace(url)
+	}
+	if err := checkDestinations(pathToUrls); err != nil {
+		return nil, err
+	}
+	return pathToUrls, nil
+}
@@ -0,0 +1,46 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestEnvHandler(t *testing.T) {
+	t.Setenv("URLSHORT_TEST_MAP", " /a = https://example.com/a?q=1 , /b=https://example.com/b,")
+	h, err := EnvHandler("URLSHORT_TEST_MAP", http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"/a": "https://example.com/a?q=1",
+		"/b": "https://example.com/b",
+	} {
+		if got := get(h, path).Header().Get("Location"); got != want {
+			t.Errorf("%s: got Location %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestEnvHandlerEmpty(t *testing.T) {
+	t.Setenv("URLSHORT_TEST_MAP", "")
+	h, err := EnvHandler("URLSHORT_TEST_MAP", http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := get(h, "/a"); rec.Code != http.StatusNotFound {
+		t.Errorf("got code %d, want fallback", rec.Code)
+	}
+
+	if _, err := EnvHandler("URLSHORT_TEST_UNSET", http.NotFoundHandler()); err != nil {
+		t.Errorf("unset variable: %v", err)
+	}
+}
+
+func TestEnvHandlerMalformed(t *testing.T) {
+	for _, value := range []string{"/a", "/a=https://example.com,/b", "/a="} {
+		t.Setenv("URLSHORT_TEST_MAP", value)
+		if _, err := EnvHandler("URLSHORT_TEST_MAP", http.NotFoundHandler()); err == nil {
+			t.Errorf("%q: accepted", value)
+		}
+	}
+}
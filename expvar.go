@@ -0,0 +1,50 @@
+package urlshort
+
+import (
+	"expvar"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+var expvarMu sync.Mutex
+
+// ExpvarHandler returns an http.Handler that calls h and
+// counts its responses in an expvar.Map published under name.
+// The map holds "hits" for redirects, "misses" for everything
+// else, and "status_<code>" for each status code written.
+//
+// Calling ExpvarHandler again with the same name reuses the
+// already published map, so several handlers can share it
+// without expvar panicking on duplicate registration. It
+// panics if name is already used by a variable that is not an
+// *expvar.Map.
+func ExpvarHandler(name string, h http.Handler) http.Handler {
+	m := expvarMap(name)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w}
+		h.ServeHTTP(rec, r)
+
+		if rec.isRedirect() {
+			m.Add("hits", 1)
+		} else {
+			m.Add("misses", 1)
+		}
+		m.Add("status_"+strconv.Itoa(rec.code()), 1)
+	})
+}
+
+func expvarMap(name string) *expvar.Map {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+
+	if v := expvar.Get(name); v != nil {
+		m, ok := v.(*expvar.Map)
+		if !ok {
+			panic("urlshort: expvar " + strconv.Quote(name) + " is not an *expvar.Map")
+		}
+		return m
+	}
+	return expvar.NewMap(name)
+}
@@ -0,0 +1,44 @@
+package urlshort
+
+import (
+	"expvar"
+	"net/http"
+	"testing"
+)
+
+func TestExpvarHandler(t *testing.T) {
+	m := MapHandler(map[string]string{"/a": "https://example.com/a"}, http.NotFoundHandler())
+	h := ExpvarHandler("urlshort_test_shared", m)
+	// A second handler with the same name shares the map
+	// instead of panicking.
+	h2 := ExpvarHandler("urlshort_test_shared", m)
+	v := expvar.Get("urlshort_test_shared").(*expvar.Map)
+	v.Init()
+
+	get(h, "/a")
+	get(h2, "/a")
+	get(h, "/missing")
+
+	for key, want := range map[string]string{
+		"hits":       "2",
+		"misses":     "1",
+		"status_302": "2",
+		"status_404": "1",
+	} {
+		if got := v.Get(key); got == nil || got.String() != want {
+			t.Errorf("%s = %v, want %s", key, got, want)
+		}
+	}
+}
+
+func TestExpvarHandlerNameTaken(t *testing.T) {
+	if expvar.Get("urlshort_test_int") == nil {
+		expvar.NewInt("urlshort_test_int")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for a name used by an *expvar.Int")
+		}
+	}()
+	ExpvarHandler("urlshort_test_int", http.NotFoundHandler())
+}
@@ -0,0 +1,91 @@
+package urlshort
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ErrorHandler handles a request whose destination could not
+// be looked up because the backend failed with err. It may
+// respond with an error status, serve a custom page, or pass
+// the request on to a fallback.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// NotFoundFallback returns an http.Handler suitable as the
+// fallback of MapHandler and friends. It responds with
+// http.StatusNotFound and message as a plain text body.
+func NotFoundFallback(message string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, message)
+	})
+}
+
+// DefaultRedirect returns an http.Handler suitable as the
+// fallback of MapHandler and friends. It redirects every
+// request to dest with the given status, or http.StatusFound
+// if status is not a 3xx code.
+//
+// A request that is already for dest itself, such as "/home"
+// when dest is "/home", gets http.NotFound instead so that an
+// unmapped dest does not redirect to itself forever.
+func DefaultRedirect(dest string, status int) http.Handler {
+	if !isRedirectStatus(status) {
+		status = http.StatusFound
+	}
+	target, err := url.Parse(dest)
+	if err != nil {
+		target = nil
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if target != nil && (target.Host == "" || target.Host == r.Host) && target.Path == r.URL.Path {
+			http.NotFound(w, r)
+			return
+		}
+
+		http.Redirect(w, r, dest, status)
+	})
+}
+
+// PrefixFallback returns an http.Handler suitable as the
+// fallback of MapHandler and friends. It passes each request
+// to the handler registered for the longest prefix of its
+// path, or to fallback if no prefix matches. Since it is only
+// consulted for unmatched paths, exact and wildcard matches
+// still take priority.
+//
+// Prefixes match whole path segments: "/blog" and "/blog/"
+// both match "/blog" and "/blog/post", but not "/blogger". A
+// trailing "*" as in "/blog/*" is ignored.
+func PrefixFallback(prefixes map[string]http.Handler, fallback http.Handler) http.Handler {
+	type scoped struct {
+		prefix  string
+		handler http.Handler
+	}
+	var scopes []scoped
+	for prefix, h := range prefixes {
+		prefix = strings.TrimSuffix(strings.TrimSuffix(prefix, "*"), "/")
+		scopes = append(scopes, scoped{normalizePath(prefix), h})
+	}
+	sort.Slice(scopes, func(i, j int) bool {
+		return len(scopes[i].prefix) > len(scopes[j].prefix)
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		for _, s := range scopes {
+			if path == s.prefix || strings.HasPrefix(path, s.prefix+"/") {
+				s.handler.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		fallback.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,91 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNotFoundFallback(t *testing.T) {
+	h := MapHandler(map[string]string{"/a": "https://example.com/"}, NotFoundFallback("no such link"))
+
+	rec := get(h, "/missing")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("got code %d, want 404", rec.Code)
+	}
+	if got := rec.Body.String(); got != "no such link" {
+		t.Errorf("got body %q", got)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("got Content-Type %q", got)
+	}
+	if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("got X-Content-Type-Options %q", got)
+	}
+}
+
+func TestDefaultRedirect(t *testing.T) {
+	h := MapHandler(map[string]string{"/a": "https://example.com/a"}, DefaultRedirect("/home", http.StatusMovedPermanently))
+
+	rec := get(h, "/whatever")
+	if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/home" {
+		t.Errorf("/whatever: got %d %q, want 301 /home", rec.Code, rec.Header().Get("Location"))
+	}
+	if rec := get(h, "/a"); rec.Header().Get("Location") != "https://example.com/a" {
+		t.Errorf("/a: mapped path sent to the fallback")
+	}
+	if rec := get(h, "/home"); rec.Code != http.StatusNotFound {
+		t.Errorf("/home: got code %d, want 404 rather than a redirect loop", rec.Code)
+	}
+}
+
+func TestDefaultRedirectStatus(t *testing.T) {
+	for _, status := range []int{0, http.StatusOK, http.StatusNotFound} {
+		if rec := get(DefaultRedirect("https://example.com/", status), "/a"); rec.Code != http.StatusFound {
+			t.Errorf("status %d: got code %d, want 302", status, rec.Code)
+		}
+	}
+}
+
+func TestDefaultRedirectOtherHost(t *testing.T) {
+	// Requests from get are for the host example.com.
+	h := DefaultRedirect("https://www.example.com/home", http.StatusFound)
+	if rec := get(h, "/home"); rec.Code != http.StatusFound {
+		t.Errorf("got code %d, want a redirect to the other host", rec.Code)
+	}
+	h = DefaultRedirect("https://example.com/home", http.StatusFound)
+	if rec := get(h, "/home"); rec.Code != http.StatusNotFound {
+		t.Errorf("same host: got code %d, want 404", rec.Code)
+	}
+}
+
+func TestPrefixFallback(t *testing.T) {
+	fallback := PrefixFallback(map[string]http.Handler{
+		"/blog/*":    DefaultRedirect("https://blog.example.com/", http.StatusFound),
+		"/blog/2024": DefaultRedirect("https://blog.example.com/2024", http.StatusFound),
+		"help/":      DefaultRedirect("https://example.com/help", http.StatusFound),
+	}, http.NotFoundHandler())
+	h := WildcardHandler(map[string]string{
+		"/blog/hello": "https://example.com/hello",
+		"/docs/*":     "https://docs.example.com/",
+	}, fallback)
+
+	tests := []struct {
+		target, want string
+	}{
+		{"/blog/hello", "https://example.com/hello"},
+		{"/blog/other", "https://blog.example.com/"},
+		{"/blog", "https://blog.example.com/"},
+		{"/blog/2024", "https://blog.example.com/2024"},
+		{"/blog/2024/a", "https://blog.example.com/2024"},
+		{"/blog/20245", "https://blog.example.com/"},
+		{"/help/a", "https://example.com/help"},
+		{"/docs/a", "https://docs.example.com/a"},
+		{"/blogger", ""},
+		{"/other", ""},
+	}
+	for _, tt := range tests {
+		if got := get(h, tt.target).Header().Get("Location"); got != tt.want {
+			t.Errorf("%s: got Location %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package urlshort
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// FSHandler reads the named file from fsys, such as an
+// embed.FS, and returns a handler for it like YAMLHandler or
+// JSONHandler would. The format is picked by the file
+// extension: .yaml or .yml, .json, .jsonl, .toml, .csv, .xml
+// or .ini.
+//
+// An error is returned if the extension is not one of these,
+// or if the file cannot be read or parsed.
+func FSHandler(fsys fs.FS, filename string, fallback http.Handler) (http.HandlerFunc, error) {
+	format := strings.TrimPrefix(path.Ext(filename), ".")
+	if format == "" {
+		return nil, fmt.Errorf("urlshort: %s: no file extension to detect the format from", filename)
+	}
+	data, err := fs.ReadFile(fsys, filename)
+	if err != nil {
+		return nil, err
+	}
+	h, err := formatHandler(format, data, fallback)
+	if err != nil {
+		return nil, fmt.Errorf("urlshort: %s: %w", filename, err)
+	}
+	return h, nil
+}
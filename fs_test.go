@@ -0,0 +1,47 @@
+package urlshort
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFSHandler(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/r.yaml": {Data: []byte("- path: /a\n  url: https://example.com/a\n")},
+		"r.YML":         {Data: []byte("- path: /a\n  url: https://example.com/a\n")},
+		"r.json":        {Data: []byte(`[{"path": "/a", "url": "https://example.com/a"}]`)},
+		"r.jsonl":       {Data: []byte(`{"path": "/a", "url": "https://example.com/a"}`)},
+		"r.csv":         {Data: []byte("/a,https://example.com/a\n")},
+		"r.ini":         {Data: []byte("[redirects]\n/a = https://example.com/a\n")},
+	}
+	for _, name := range []string{"config/r.yaml", "r.YML", "r.json", "r.jsonl", "r.csv", "r.ini"} {
+		h, err := FSHandler(fsys, name, http.NotFoundHandler())
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if got := get(h, "/a").Header().Get("Location"); got != "https://example.com/a" {
+			t.Errorf("%s: got Location %q, want https://example.com/a", name, got)
+		}
+	}
+}
+
+func TestFSHandlerErrors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"r.txt":  {Data: []byte("/a https://example.com/a\n")},
+		"r":      {Data: []byte("/a https://example.com/a\n")},
+		"r.yaml": {Data: []byte("- path: [")},
+	}
+	for _, name := range []string{"r.txt", "r", "r.yaml"} {
+		if _, err := FSHandler(fsys, name, http.NotFoundHandler()); err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("%s: got error %v, want one naming the file", name, err)
+		}
+	}
+	if _, err := FSHandler(fsys, "missing.yaml", http.NotFoundHandler()); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing.yaml: got error %v, want fs.ErrNotExist", err)
+	}
+}
@@ -1,9 +1,21 @@
 package urlshort
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"gopkg.in/yaml.v3"
 )
 
@@ -13,11 +25,163 @@ import (
 // that each key in the map points to, in string format).
 // If the path is not provided in the map, then the fallback
 // http.Handler will be called instead.
+//
+// The root path "/" may be mapped like any other, typically to
+// an absolute URL. MapHandler does not check for a "/" entry
+// whose relative destination resolves back to "/", which would
+// loop forever; the config based handlers reject such entries,
+// and Validate reports them.
 func MapHandler(pathsToUrls map[string]string, fallback http.Handler) http.HandlerFunc {
+	return MapHandlerWithStatus(pathsToUrls, http.StatusFound, fallback)
+}
+
+// MapHandlerWithStatus works like MapHandler, but every
+// redirect is issued with the provided status code instead
+// of http.StatusFound. If status is not a 3xx code, then
+// http.StatusFound is used.
+func MapHandlerWithStatus(pathsToUrls map[string]string, status int, fallback http.Handler) http.HandlerFunc {
+	return MapHandlerWithOptions(pathsToUrls, Options{Status: status}, fallback)
+}
+
+// Options configures the behavior of MapHandlerWithOptions.
+// The zero value matches the behavior of MapHandler.
+type Options struct {
+	// Status is the code used for every redirect. If it is
+	// not a 3xx code, then http.StatusFound is used.
+	Status int
+
+	// PreserveQuery merges the query string of the incoming
+	// request onto the destination URL, after any query the
+	// destination already has.
+	PreserveQuery bool
+
+	// CaseInsensitive matches request paths against the map
+	// keys without regard to case. An exact match is always
+	// preferred; among keys that differ only in case, the one
+	// that sorts first wins.
+	CaseInsensitive bool
+
+	// Wildcard enables keys ending in "/*", which match any
+	// path below that prefix. The remainder of the request
+	// path is appended to the path of the destination, after a
+	// slash if it has none, so "/docs/*" to
+	// "https://docs.example.com" sends "/docs/a/b" to
+	// "https://docs.example.com/a/b". The remainder is escaped,
+	// so it cannot change the host or add a query or fragment;
+	// any query of the destination is kept. Exact matches take
+	// priority, then the longest matching wildcard.
+	Wildcard bool
+
+	// IgnoreTrailingSlash treats "/github" and "/github/" as
+	// the same path, both in the map keys and in requests.
+	// The root path "/" is not affected.
+	IgnoreTrailingSlash bool
+
+	// NormalizeUnicode matches non-ASCII paths regardless of
+	// how they are encoded: map keys are percent-decoded, and
+	// both keys and request paths are normalized to Unicode
+	// NFC, so a request for "/caf%C3%A9" matches a "/café" key
+	// and vice versa.
+	NormalizeUnicode bool
+
+	// QueryParams are added to the query of every destination,
+	// for example {"ref": "shortener"} for attribution. A
+	// parameter the destination already has is left as is.
+	QueryParams map[string]string
+
+	// BeforeRedirect, if set, is called with the request and
+	// its matched destination before redirecting. It returns
+	// the destination to use, which may differ from dest, and
+	// false to skip the redirect and call the fallback instead.
+	// It is not called for requests without a match.
+	BeforeRedirect func(r *http.Request, dest string) (string, bool)
+
+	// CacheMaxAge, if positive, adds a Cache-Control header to
+	// every redirect: permanent redirects (301 and 308) may be
+	// cached for CacheMaxAge, while all others are marked
+	// no-cache.
+	CacheMaxAge time.Duration
+
+	// AllowedHosts, if not empty, restricts redirects to
+	// destinations on these hosts, including ones produced by
+	// BeforeRedirect; other requests are passed to the
+	// fallback. See ValidateHosts for the matching rules, and
+	// AllowedHostsHandler to reject a static map with
+	// disallowed destinations up front.
+	AllowedHosts []string
+
+	// LinkBody writes a short HTML body linking to the
+	// destination along with every redirect, for clients that
+	// ignore the Location header. http.Redirect already does
+	// this for GET and HEAD requests; LinkBody extends it to
+	// all methods.
+	LinkBody bool
+
+	// JSONResponse answers requests whose Accept header
+	// prefers application/json over text/html with
+	// http.StatusOK and a {"path": "...", "url": "..."} body
+	// instead of a redirect, for API clients that would rather
+	// not follow one. Other requests are redirected as usual.
+	JSONResponse bool
+
+	// UpgradeHTTPS rewrites destinations using http to https
+	// before redirecting, dropping an explicit port 80. If
+	// UpgradeHosts is not empty, only destinations on those
+	// hosts are upgraded; it takes the patterns described by
+	// ValidateHosts. Relative destinations are left as is.
+	UpgradeHTTPS bool
+	UpgradeHosts []string
+
+	// DefaultScheme, if set, is prepended to destinations that
+	// name a host but no scheme, such as "example.com/foo",
+	// which would otherwise redirect to a path on the same
+	// host. A destination is taken to start with a host when
+	// its text up to the first "/", "?" or "#" is a host name
+	// containing a dot, or "localhost", optionally followed by
+	// a port. Destinations with a scheme, or starting with "/",
+	// "." or another character that cannot start a host, are
+	// left as is; write relative destinations such as
+	// "page.html" as "./page.html" to keep them relative.
+	DefaultScheme string
+}
+
+// MapHandlerWithOptions works like MapHandler, but the
+// redirects it issues are configured by opts.
+func MapHandlerWithOptions(pathsToUrls map[string]string, opts Options, fallback http.Handler) http.HandlerFunc {
+	status := opts.Status
+	if !isRedirectStatus(status) {
+		status = http.StatusFound
+	}
+	rs := newResolver(pathsToUrls, opts)
+	prepared := make(map[string]preparedRedirect, len(rs.pathsToUrls))
+	for _, dest := range rs.pathsToUrls {
+		if p, ok := prepareRedirect(dest, status); ok {
+			prepared[dest] = p
+		}
+	}
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
-		if dest, ok := pathsToUrls[path]; ok {
-			http.Redirect(w, r, dest, http.StatusFound)
+		if dest, ok := rs.resolve(r, path); ok {
+			if opts.JSONResponse {
+				w.Header().Add("Vary", "Accept")
+				if prefersJSON(r.Header.Get("Accept")) {
+					writeJSON(w, http.StatusOK, map[string]string{"path": path, "url": dest})
+					return
+				}
+			}
+			if opts.CacheMaxAge > 0 {
+				setCacheControl(w, status, opts.CacheMaxAge)
+			}
+			if opts.LinkBody {
+				redirectWithBody(w, r, dest, status)
+				return
+			}
+			if p, ok := prepared[dest]; ok {
+				p.serve(w, r, status)
+				return
+			}
+			http.Redirect(w, r, dest, status)
 			return
 		}
 
@@ -25,6 +189,154 @@ func MapHandler(pathsToUrls map[string]string, fallback http.Handler) http.Handl
 	}
 }
 
+// redirectWithBody works like http.Redirect, but writes an
+// HTML link to dest whatever the request method.
+func redirectWithBody(w http.ResponseWriter, r *http.Request, dest string, status int) {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		http.Redirect(w, r, dest, status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	http.Redirect(w, r, dest, status)
+	location := html.EscapeString(w.Header().Get("Location"))
+	fmt.Fprintf(w, "<a href=\"%s\">%s</a>.\n", location, http.StatusText(status))
+}
+
+// withDefaultScheme returns a copy of pathsToUrls with scheme
+// prepended to every destination that starts with a host, as
+// described by Options.DefaultScheme.
+func withDefaultScheme(pathsToUrls map[string]string, scheme string) map[string]string {
+	converted := make(map[string]string, len(pathsToUrls))
+	for path, dest := range pathsToUrls {
+		if startsWithHost(dest) {
+			dest = scheme + "://" + dest
+		}
+		converted[path] = dest
+	}
+	return converted
+}
+
+func startsWithHost(dest string) bool {
+	if strings.Contains(dest, "://") {
+		return false
+	}
+	end := strings.IndexAny(dest, "/?#")
+	if end < 0 {
+		end = len(dest)
+	}
+	host, port, hasPort := strings.Cut(dest[:end], ":")
+	if hasPort && (port == "" || strings.Trim(port, "0123456789") != "") {
+		return false
+	}
+	if host != "localhost" && !strings.Contains(host, ".") {
+		return false
+	}
+	for _, c := range host {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '.') {
+			return false
+		}
+	}
+	return host[0] != '.' && host[0] != '-'
+}
+
+// upgradeHTTPS returns dest with its scheme changed from http
+// to https if its host matches hosts, or hosts is empty. If
+// dest cannot be parsed it is returned unchanged.
+func upgradeHTTPS(dest string, hosts []string) string {
+	u, err := url.Parse(dest)
+	if err != nil || !strings.EqualFold(u.Scheme, "http") {
+		return dest
+	}
+	if len(hosts) > 0 && !hostMatches(u.Hostname(), hosts) {
+		return dest
+	}
+	u.Scheme = "https"
+	u.Host = strings.TrimSuffix(u.Host, ":80")
+	return u.String()
+}
+
+// prefersJSON reports whether the Accept header accept ranks
+// application/json above text/html. Wildcards are ignored.
+func prefersJSON(accept string) bool {
+	var jsonQ, htmlQ float64
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case "application/json":
+			jsonQ = max(jsonQ, q)
+		case "text/html":
+			htmlQ = max(htmlQ, q)
+		}
+	}
+	return jsonQ > 0 && jsonQ > htmlQ
+}
+
+func setCacheControl(w http.ResponseWriter, status int, maxAge time.Duration) {
+	if status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect {
+		w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(maxAge.Seconds())))
+		return
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+}
+
+// mergeQuery appends rawQuery to the query of dest. If dest
+// cannot be parsed it is returned unchanged.
+func mergeQuery(dest, rawQuery string) string {
+	if rawQuery == "" {
+		return dest
+	}
+	u, err := url.Parse(dest)
+	if err != nil {
+		return dest
+	}
+	if u.RawQuery == "" {
+		u.RawQuery = rawQuery
+	} else {
+		u.RawQuery += "&" + rawQuery
+	}
+	return u.String()
+}
+
+// addQueryParams adds each of params missing from the query
+// of dest. If dest cannot be parsed it is returned unchanged.
+func addQueryParams(dest string, params map[string]string) string {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return dest
+	}
+	existing := u.Query()
+	var added []string
+	for _, key := range sortedKeys(params) {
+		if !existing.Has(key) {
+			added = append(added, url.QueryEscape(key)+"="+url.QueryEscape(params[key]))
+		}
+	}
+	if len(added) == 0 {
+		return dest
+	}
+	if u.RawQuery != "" {
+		added = append([]string{u.RawQuery}, added...)
+	}
+	u.RawQuery = strings.Join(added, "&")
+	return u.String()
+}
+
+// WildcardHandler works like MapHandler, but also supports
+// keys with a trailing wildcard such as "/docs/*". See
+// Options.Wildcard for the matching rules.
+func WildcardHandler(pathsToUrls map[string]string, fallback http.Handler) http.HandlerFunc {
+	return MapHandlerWithOptions(pathsToUrls, Options{Wildcard: true}, fallback)
+}
+
 // YAMLHandler will parse the provided YAML and then return
 // an http.HandlerFunc (which also implements http.Handler)
 // that will attempt to map any paths to their corresponding
@@ -35,72 +347,907 @@ func MapHandler(pathsToUrls map[string]string, fallback http.Handler) http.Handl
 //
 //   - path: /some-path
 //     url: https://www.some-url.com/demo
+//   - path: /moved
+//     url: https://www.some-url.com/new-home
+//     status: 301
+//   - path: /campaign
+//     url: https://www.some-url.com/sale
+//     expires: 2024-01-31T23:59:59Z
+//   - path: /promo
+//     destinations: [{url: "https://www.some-url.com/a", weight: 3}, {url: "https://www.some-url.com/b"}]
+//   - path: /api
+//     url: https://api.some-url.com
+//     methods: [GET, HEAD]
+//   - path: /search
+//     url: https://www.some-url.com/search-help
+//     query: {q: help}
+//   - path: /gh
+//     alias: /some-path
+//   - path: /app
+//     url: https://www.some-url.com/app
+//     user_agents: [{contains: iPhone, url: "https://apps.apple.com/app/id1"}]
+//   - path: /summer-sale
+//     gone: true
+//     message: This campaign has ended.
+//
+// The optional status field sets the redirect code for that
+// entry and must be a 3xx code; it defaults to 302. The
+// optional expires field is an RFC 3339 timestamp after which
+// the entry is ignored and requests for it are passed to the
+// fallback; it is checked on every request against
+// ConfigOptions.Now. Instead of url, an entry may list several
+// weighted destinations, one of which is picked at random for
+// every request with ConfigOptions.Rand; weights are relative
+// and default to 1. The optional methods field limits the
+// entry to the listed HTTP methods, passing requests with any
+// other method to the fallback.
+// The optional query field makes an entry apply only to
+// requests carrying those query parameter values, ignoring any
+// other parameters; such entries are tried in order before the
+// entry for the same path without a query, if there is one.
+// Instead of url, an entry may give the path of another entry
+// as alias to redirect the same way; aliases may refer to
+// other aliases, but must not form a cycle, and an alias's
+// path cannot also have entries with a query. The optional
+// user_agents field lists alternate destinations for requests
+// whose User-Agent header contains a string, ignoring case, or
+// matches a regex; the first matching one is used, and url
+// otherwise. An entry with gone set to true marks a retired
+// link: it needs no url and is answered with 410 Gone and the
+// optional message instead of a redirect, unlike a removed
+// entry, whose requests go to the fallback. Setting disabled to
+// true keeps an entry in the file but stops it from
+// redirecting.
+//
+// The list may also be given under a redirects key, next to
+// an optional fallback url that replaces the fallback
+// http.Handler with a redirect to it, as by DefaultRedirect:
+//
+//	fallback: https://www.some-url.com/
+//	ignore_query: [utm_*, fbclid]
+//	redirects:
+//	  - path: /some-path
+//	    url: https://www.some-url.com/demo
+//
+// The optional ignore_query list names query parameters, or
+// prefixes of them followed by "*", that never take part in
+// matching, such as tracking parameters. Entries whose query
+// field uses one are rejected. Requests carrying them still
+// match entries by their other parameters, and the parameters
+// are left on the request for the fallback and any handler
+// wrapping this one.
 //
 // The only errors that can be returned all related to having
-// invalid YAML data.
+// invalid YAML data, including entries with an empty url or
+// an invalid status or expiry.
 //
 // See MapHandler to create a similar http.HandlerFunc via
 // a mapping of paths to urls.
 func YAMLHandler(yml []byte, fallback http.Handler) (http.HandlerFunc, error) {
-	pathUrls, err := parseYaml(yml)
+	return YAMLHandlerWithOptions(yml, ConfigOptions{}, fallback)
+}
+
+// YAMLHandlerWithOptions works like YAMLHandler, configured by
+// opts.
+func YAMLHandlerWithOptions(yml []byte, opts ConfigOptions, fallback http.Handler) (http.HandlerFunc, error) {
+	cfg, err := parseYamlConfig(yml, false)
 	if err != nil {
 		return nil, err
 	}
 
-	pathsToUrls := buildMapYaml(pathUrls)
-	return MapHandler(pathsToUrls, fallback), nil
+	entries, err := buildEntriesYaml(cfg.Redirects)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIgnoredQuery(entries, cfg.IgnoreQuery); err != nil {
+		return nil, err
+	}
+	return entryHandler(entries, opts, configFallback(cfg.Fallback, fallback)), nil
 }
 
+// JSONHandler is like YAMLHandler, but parses JSON in the
+// format:
+//
+//	[
+//	  {"path": "/some-path", "url": "https://www.some-url.com/demo"},
+//	  {"path": "/campaign", "url": "https://www.some-url.com/sale", "expires": "2024-01-31T23:59:59Z"}
+//	]
+//
+// The optional expires field is an RFC 3339 timestamp after
+// which the entry is ignored and requests for it are passed
+// to the fallback. As in YAMLHandler, an entry may give a
+// list of weighted destinations instead of url:
+//
+//	{"path": "/promo", "destinations": [{"url": "https://a.example.com", "weight": 3}, {"url": "https://b.example.com"}]}
+//
+// and may restrict itself to some HTTP methods with
+// "methods": ["GET"], to requests with some query parameter
+// values with "query": {"q": "help"}, pick destinations by
+// user agent with "user_agents": [{"regex": "(?i)android",
+// "url": "..."}], refer to another entry with
+// "alias": "/some-path", be retired with "gone": true and an
+// optional "message", or be switched off with
+// "disabled": true.
+//
+// A flat object mapping paths to urls is accepted as well:
+//
+//	{"/some-path": "https://www.some-url.com/demo"}
+//
+// as is an object giving the list under "redirects", with an
+// optional "fallback" url and "ignore_query" list as in
+// YAMLHandler:
+//
+//	{"fallback": "https://www.some-url.com/", "ignore_query": ["utm_*"], "redirects": [...]}
+//
+// The fallback and ignore_query keys are only allowed next to
+// redirects; a flat object using them is an error rather than
+// a mapping of the paths "fallback" and "ignore_query".
 func JSONHandler(jsn []byte, fallback http.Handler) (http.HandlerFunc, error) {
-	pathUrls, err := parseJson(jsn)
+	return JSONHandlerWithOptions(jsn, ConfigOptions{}, fallback)
+}
+
+// JSONHandlerWithOptions works like JSONHandler, configured by
+// opts.
+func JSONHandlerWithOptions(jsn []byte, opts ConfigOptions, fallback http.Handler) (http.HandlerFunc, error) {
+	cfg, err := parseJsonConfig(jsn, false)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := buildEntriesJson(cfg.Redirects)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIgnoredQuery(entries, cfg.IgnoreQuery); err != nil {
+		return nil, err
+	}
+	return entryHandler(entries, opts, configFallback(cfg.Fallback, fallback)), nil
+}
+
+// configFallback returns a DefaultRedirect to url if the config
+// gave one, and fallback otherwise.
+func configFallback(url string, fallback http.Handler) http.Handler {
+	if strings.TrimSpace(url) == "" {
+		return fallback
+	}
+	return DefaultRedirect(url, http.StatusFound)
+}
+
+// TOMLHandler will parse the provided TOML and then return
+// an http.HandlerFunc (which also implements http.Handler)
+// that will attempt to map any paths to their corresponding
+// URL. If the path is not provided in the TOML, then the
+// fallback http.Handler will be called instead.
+//
+// TOML is expected to be in the format:
+//
+//	[[paths]]
+//	path = "/some-path"
+//	url = "https://www.some-url.com/demo"
+//
+// The only errors that can be returned all related to having
+// invalid TOML data, including entries with an empty url.
+func TOMLHandler(tml []byte, fallback http.Handler) (http.HandlerFunc, error) {
+	pathsToUrls, err := ParseTOML(tml)
+	if err != nil {
+		return nil, err
+	}
+
+	return MapHandler(pathsToUrls, fallback), nil
+}
+
+// CSVHandler will parse the provided CSV and then return
+// an http.HandlerFunc (which also implements http.Handler)
+// that will attempt to map any paths to their corresponding
+// URL. If the path is not provided in the CSV, then the
+// fallback http.Handler will be called instead.
+//
+// CSV is expected to have two columns, optionally preceded
+// by a header row:
+//
+//	path,url
+//	/some-path,https://www.some-url.com/demo
+//
+// The column order is taken from the header, so "url,path"
+// works as well. Without a header, path is assumed to be the
+// first column.
+//
+// The only errors that can be returned all related to having
+// invalid CSV data, including rows with the wrong number of
+// columns or an empty url.
+func CSVHandler(csvData []byte, fallback http.Handler) (http.HandlerFunc, error) {
+	pathsToUrls, err := ParseCSV(csvData)
+	if err != nil {
+		return nil, err
+	}
+
+	return MapHandler(pathsToUrls, fallback), nil
+}
+
+// XMLHandler will parse the provided XML and then return
+// an http.HandlerFunc (which also implements http.Handler)
+// that will attempt to map any paths to their corresponding
+// URL. If the path is not provided in the XML, then the
+// fallback http.Handler will be called instead.
+//
+// XML is expected to have a root element containing one
+// element per entry, with path and url given either as child
+// elements or as attributes:
+//
+//	<redirects>
+//	  <redirect>
+//	    <path>/some-path</path>
+//	    <url>https://www.some-url.com/demo</url>
+//	  </redirect>
+//	  <redirect path="/other-path" url="https://www.some-url.com/other"/>
+//	</redirects>
+//
+// The names of the root and entry elements are not checked.
+//
+// The only errors that can be returned all related to having
+// invalid XML data, including entries with an empty url.
+func XMLHandler(xmlData []byte, fallback http.Handler) (http.HandlerFunc, error) {
+	pathsToUrls, err := ParseXML(xmlData)
 	if err != nil {
 		return nil, err
 	}
 
-	pathsToUrls := buildMapJson(pathUrls)
 	return MapHandler(pathsToUrls, fallback), nil
 }
 
-func parseJson(data []byte) ([]pathUrlJson, error) {
+// INIHandler will parse the provided INI data and then return
+// an http.HandlerFunc (which also implements http.Handler)
+// that will attempt to map any paths to their corresponding
+// URL. If the path is not provided in the INI data, then the
+// fallback http.Handler will be called instead.
+//
+// Redirects are read from the [redirects] section, with a
+// path as each key and its URL as the value:
+//
+//	[redirects]
+//	; comments start with ; or #
+//	/some-path = https://www.some-url.com/demo
+//
+// Other sections are ignored. The only errors that can be
+// returned all related to having invalid INI data, including
+// entries with an empty url.
+func INIHandler(iniData []byte, fallback http.Handler) (http.HandlerFunc, error) {
+	pathsToUrls, err := ParseINI(iniData)
+	if err != nil {
+		return nil, err
+	}
+
+	return MapHandler(pathsToUrls, fallback), nil
+}
+
+// formatHandler builds a handler from data in the named
+// format, which is one of "yaml" (or "yml"), "json", "jsonl",
+// "toml", "csv", "xml" and "ini". Binary protobuf configs are
+// left out, as they are not text files to be told apart by
+// extension; use urlshortpb.ConfigHandler for them.
+func formatHandler(format string, data []byte, fallback http.Handler) (http.HandlerFunc, error) {
+	switch strings.ToLower(format) {
+	case "yaml", "yml":
+		return YAMLHandler(data, fallback)
+	case "json":
+		return JSONHandler(data, fallback)
+	case "jsonl":
+		return JSONLHandler(bytes.NewReader(data), fallback)
+	case "toml":
+		return TOMLHandler(data, fallback)
+	case "csv":
+		return CSVHandler(data, fallback)
+	case "xml":
+		return XMLHandler(data, fallback)
+	case "ini":
+		return INIHandler(data, fallback)
+	}
+	return nil, fmt.Errorf("urlshort: unknown format %q", format)
+}
+
+// ParseYAML parses YAML in the format accepted by YAMLHandler
+// and returns the resulting mapping of paths to urls, which
+// can be inspected or modified before being passed to
+// MapHandler. It rejects the same input as YAMLHandler,
+// including entries whose query uses a parameter listed in
+// ignore_query.
+//
+// ParseYAML and the other Parse functions accept arbitrary
+// input, including nil, and report malformed input as an error
+// rather than panicking, so they can serve as fuzz targets.
+func ParseYAML(yml []byte) (map[string]string, error) {
+	return ParseYAMLWithOptions(yml, ParseOptions{})
+}
+
+// ParseJSON is like ParseYAML, but for the format accepted by
+// JSONHandler.
+func ParseJSON(jsn []byte) (map[string]string, error) {
+	return ParseJSONWithOptions(jsn, ParseOptions{})
+}
+
+// ParseOptions configures ParseYAMLWithOptions and
+// ParseJSONWithOptions. The zero value matches ParseYAML and
+// ParseJSON.
+type ParseOptions struct {
+	// DisallowDuplicates makes parsing fail when the same path
+	// is listed more than once with the same query, instead of
+	// the last entry silently winning. Entries for one path
+	// with different queries are variants, not duplicates.
+	DisallowDuplicates bool
+
+	// DisallowLoops makes parsing fail when relative
+	// destinations redirect paths to each other in a cycle, as
+	// reported by CheckLoops.
+	DisallowLoops bool
+
+	// EncodeURLs re-encodes every destination with EncodeURLs,
+	// so that unencoded characters such as spaces do not
+	// produce a broken Location header.
+	EncodeURLs bool
+
+	// DisallowUnknownFields makes parsing fail on fields the
+	// format does not define, such as a misspelled "ur" for
+	// "url", instead of silently ignoring them. It is meant to
+	// catch typos; extra metadata fields such as "owner" are
+	// then rejected too.
+	DisallowUnknownFields bool
+}
+
+// ParseYAMLWithOptions works like ParseYAML, with additional
+// checks configured by opts.
+func ParseYAMLWithOptions(yml []byte, opts ParseOptions) (map[string]string, error) {
+	cfg, err := parseYamlConfig(yml, opts.DisallowUnknownFields)
+	if err != nil {
+		return nil, err
+	}
+	pathUrls := cfg.Redirects
+	if opts.DisallowDuplicates {
+		paths := make([]string, len(pathUrls))
+		for i, pu := range pathUrls {
+			paths[i] = duplicateKey(pu.Path, pu.Query)
+		}
+		if err := checkDuplicates(paths); err != nil {
+			return nil, err
+		}
+	}
+	entries, err := buildEntriesYaml(pathUrls)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIgnoredQuery(entries, cfg.IgnoreQuery); err != nil {
+		return nil, err
+	}
+	return checkParsed(entryUrls(entries), opts)
+}
+
+// ParseJSONWithOptions works like ParseJSON, with additional
+// checks configured by opts.
+func ParseJSONWithOptions(jsn []byte, opts ParseOptions) (map[string]string, error) {
+	cfg, err := parseJsonConfig(jsn, opts.DisallowUnknownFields)
+	if err != nil {
+		return nil, err
+	}
+	pathUrls := cfg.Redirects
+	if opts.DisallowDuplicates {
+		paths := make([]string, len(pathUrls))
+		for i, pu := range pathUrls {
+			paths[i] = duplicateKey(pu.Path, pu.Query)
+		}
+		if err := checkDuplicates(paths); err != nil {
+			return nil, err
+		}
+	}
+	entries, err := buildEntriesJson(pathUrls)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIgnoredQuery(entries, cfg.IgnoreQuery); err != nil {
+		return nil, err
+	}
+	return checkParsed(entryUrls(entries), opts)
+}
+
+// checkParsed runs the checks and conversions of opts that
+// apply to a parsed mapping.
+func checkParsed(pathsToUrls map[string]string, opts ParseOptions) (map[string]string, error) {
+	if opts.EncodeURLs {
+		var err error
+		if pathsToUrls, err = EncodeURLs(pathsToUrls); err != nil {
+			return nil, err
+		}
+	}
+	if opts.DisallowLoops {
+		if err := CheckLoops(pathsToUrls); err != nil {
+			return nil, err
+		}
+	}
+	return pathsToUrls, nil
+}
+
+// ParseTOML is like ParseYAML, but for the format accepted by
+// TOMLHandler.
+func ParseTOML(tml []byte) (map[string]string, error) {
+	pathUrls, err := parseToml(tml)
+	if err != nil {
+		return nil, err
+	}
+	pathsToUrls := buildMapToml(pathUrls)
+	if err := checkDestinations(pathsToUrls); err != nil {
+		return nil, err
+	}
+	return pathsToUrls, nil
+}
+
+// ParseCSV is like ParseYAML, but for the format accepted by
+// CSVHandler.
+func ParseCSV(csvData []byte) (map[string]string, error) {
+	pathUrls, err := parseCsv(csvData)
+	if err != nil {
+		return nil, err
+	}
+	pathsToUrls := buildMapCsv(pathUrls)
+	if err := checkDestinations(pathsToUrls); err != nil {
+		return nil, err
+	}
+	return pathsToUrls, nil
+}
+
+// ParseXML is like ParseYAML, but for the format accepted by
+// XMLHandler.
+func ParseXML(xmlData []byte) (map[string]string, error) {
+	pathUrls, err := parseXml(xmlData)
+	if err != nil {
+		return nil, err
+	}
+	pathsToUrls := buildMapXml(pathUrls)
+	if err := checkDestinations(pathsToUrls); err != nil {
+		return nil, err
+	}
+	return pathsToUrls, nil
+}
+
+// normalizePath adds the leading slash that config authors
+// sometimes leave out, so that "github" matches "/github".
+func normalizePath(path string) string {
+	if path == "" || strings.HasPrefix(path, "/") {
+		return path
+	}
+	return "/" + path
+}
+
+// checkDestinations rejects empty destinations and relative
+// destinations that resolve back to their own path, such as
+// "/" mapped to "/" or "./", either of which would redirect
+// the path to itself forever. Absolute urls are always
+// allowed, so "/" may redirect to an external site.
+func checkDestinations(pathsToUrls map[string]string) error {
+	for _, path := range sortedKeys(pathsToUrls) {
+		dest := pathsToUrls[path]
+		if strings.TrimSpace(dest) == "" {
+			return fmt.Errorf("urlshort: path %q has an empty url", path)
+		}
+		if redirectsToSelf(path, dest) {
+			return fmt.Errorf("urlshort: path %q redirects to itself via %q", path, dest)
+		}
+	}
+	return nil
+}
+
+// redirectsToSelf reports whether following a redirect from
+// path to dest requests path again. The query and fragment are
+// ignored, as they play no part in matching.
+func redirectsToSelf(path, dest string) bool {
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return false
+	}
+	return (&url.URL{Path: path}).ResolveReference(u).Path == path
+}
+
+// ParseINI is like ParseYAML, but for the format accepted by
+// INIHandler.
+func ParseINI(iniData []byte) (map[string]string, error) {
+	pathUrls, err := parseIni(iniData, "redirects")
+	if err != nil {
+		return nil, err
+	}
+	pathsToUrls := buildMapIni(pathUrls)
+	if err := checkDestinations(pathsToUrls); err != nil {
+		return nil, err
+	}
+	return pathsToUrls, nil
+}
+
+// duplicateKey identifies the entry for path with the given
+// query, so that query variants of a path are not reported as
+// duplicates of it.
+func duplicateKey(path string, query map[string]string) string {
+	path = normalizePath(path)
+	if len(query) == 0 {
+		return path
+	}
+	q := make(url.Values, len(query))
+	for k, v := range query {
+		q.Set(k, v)
+	}
+	return path + "?" + q.Encode()
+}
+
+func checkDuplicates(paths []string) error {
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		if seen[path] {
+			return fmt.Errorf("urlshort: duplicate path %q", path)
+		}
+		seen[path] = true
+	}
+	return nil
+}
+
+func isRedirectStatus(status int) bool {
+	return status >= 300 && status <= 399
+}
+
+// parseJsonConfig parses any of the JSON config forms. If
+// strict is set, fields the form does not define are errors.
+func parseJsonConfig(data []byte, strict bool) (configJson, error) {
+	var cfg configJson
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return cfg, jsonShapeError(err)
+		}
+		if _, ok := obj["redirects"]; ok {
+			if err := unmarshalJson(data, &cfg, strict); err != nil {
+				return cfg, jsonShapeError(err)
+			}
+			return cfg, nil
+		}
+		for _, key := range []string{"fallback", "ignore_query"} {
+			if _, ok := obj[key]; ok {
+				return cfg, fmt.Errorf("urlshort: JSON object has %q but no \"redirects\" list; put the paths under \"redirects\"", key)
+			}
+		}
+		pathUrls, err := parseJsonObject(data)
+		cfg.Redirects = pathUrls
+		return cfg, err
+	}
+
+	err := unmarshalJson(data, &cfg.Redirects, strict)
+	if err != nil {
+		return cfg, jsonShapeError(err)
+	}
+	return cfg, nil
+}
+
+// unmarshalJson works like json.Unmarshal, but rejects unknown
+// object fields if strict is set.
+func unmarshalJson(data []byte, v any, strict bool) error {
+	if !strict {
+		return json.Unmarshal(data, v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("invalid data after top-level value")
+	}
+	return nil
+}
+
+// parseJsonObject parses the flat object form, keeping the
+// paths in the order they appear, repeats included, so that
+// duplicates can be told apart from a single entry. data must
+// already be known to hold a single JSON object.
+func parseJsonObject(data []byte) ([]pathUrlJson, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return nil, jsonShapeError(err)
+	}
 	var pathUrls []pathUrlJson
-	err := json.Unmarshal(data, &pathUrls)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, jsonShapeError(err)
+		}
+		var url string
+		if err := dec.Decode(&url); err != nil {
+			return nil, jsonShapeError(err)
+		}
+		pathUrls = append(pathUrls, pathUrlJson{Path: tok.(string), Url: url})
+	}
+	return pathUrls, nil
+}
+
+func jsonShapeError(err error) error {
+	return fmt.Errorf("urlshort: JSON must be an array of entries or an object mapping paths to urls: %w", err)
+}
+
+// parseYamlConfig parses either YAML config form. If strict is
+// set, fields the form does not define are errors.
+func parseYamlConfig(data []byte, strict bool) (configYaml, error) {
+	var cfg configYaml
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return cfg, err
+	}
+	if len(doc.Content) == 0 {
+		return cfg, nil
+	}
+	var v any = &cfg.Redirects
+	if doc.Content[0].Kind == yaml.MappingNode {
+		v = &cfg
+	}
+	if !strict {
+		err := doc.Content[0].Decode(v)
+		return cfg, err
+	}
+
+	// Nodes cannot decode strictly, so decode the data again.
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	err := dec.Decode(v)
+	return cfg, err
+}
+
+func parseToml(data []byte) ([]pathUrlToml, error) {
+	var doc struct {
+		Paths []pathUrlToml `toml:"paths"`
+	}
+	err := toml.Unmarshal(data, &doc)
 	if err != nil {
 		return nil, err
 	}
+	return doc.Paths, nil
+}
+
+func parseCsv(data []byte) ([]pathUrlCsv, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = 2
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	pathCol, urlCol := 0, 1
+	header := records[0]
+	first, second := strings.ToLower(strings.TrimSpace(header[0])), strings.ToLower(strings.TrimSpace(header[1]))
+	switch {
+	case first == "path" && second == "url":
+		records = records[1:]
+	case first == "url" && second == "path":
+		pathCol, urlCol = 1, 0
+		records = records[1:]
+	case first == "path" || first == "url" || second == "path" || second == "url":
+		return nil, fmt.Errorf("urlshort: invalid CSV header %q", header)
+	}
+
+	pathUrls := make([]pathUrlCsv, 0, len(records))
+	for _, rec := range records {
+		pathUrls = append(pathUrls, pathUrlCsv{Path: rec[pathCol], Url: rec[urlCol]})
+	}
 	return pathUrls, nil
 }
 
-func parseYaml(data []byte) ([]pathUrlYaml, error) {
-	var pathUrls []pathUrlYaml
-	err := yaml.Unmarshal(data, &pathUrls)
+func parseXml(data []byte) ([]pathUrlXml, error) {
+	var doc struct {
+		Entries []pathUrlXml `xml:",any"`
+	}
+	err := xml.Unmarshal(data, &doc)
 	if err != nil {
 		return nil, err
 	}
+	for i, pu := range doc.Entries {
+		if pu.Path == "" {
+			doc.Entries[i].Path = pu.PathAttr
+		}
+		if pu.Url == "" {
+			doc.Entries[i].Url = pu.UrlAttr
+		}
+	}
+	return doc.Entries, nil
+}
+
+func parseIni(data []byte, section string) ([]pathUrlIni, error) {
+	var pathUrls []pathUrlIni
+	current := ""
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || line[0] == ';' || line[0] == '#':
+			continue
+		case line[0] == '[':
+			if line[len(line)-1] != ']' {
+				return nil, fmt.Errorf("urlshort: line %d: malformed section header %q", i+1, line)
+			}
+			current = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("urlshort: line %d: malformed line %q, want key = value", i+1, line)
+		}
+		if current == section {
+			pathUrls = append(pathUrls, pathUrlIni{Path: strings.TrimSpace(key), Url: strings.TrimSpace(value)})
+		}
+	}
 	return pathUrls, nil
 }
 
-func buildMapYaml(pathUrls []pathUrlYaml) map[string]string {
+func buildEntriesYaml(pathUrls []pathUrlYaml) (map[string]entry, error) {
+	configs := make([]entryConfig, len(pathUrls))
+	for i, pu := range pathUrls {
+		configs[i] = entryConfig{
+			Path:     pu.Path,
+			Url:      pu.Url,
+			Status:   pu.Status,
+			Expires:  pu.Expires,
+			Methods:  pu.Methods,
+			Query:    pu.Query,
+			Alias:    pu.Alias,
+			Gone:     pu.Gone,
+			Message:  pu.Message,
+			Disabled: pu.Disabled,
+		}
+		for _, d := range pu.Destinations {
+			configs[i].Destinations = append(configs[i].Destinations, weightedUrl(d))
+		}
+		for _, a := range pu.UserAgents {
+			configs[i].Agents = append(configs[i].Agents, agentConfig(a))
+		}
+	}
+	return buildEntries(configs)
+}
+
+func buildEntriesJson(pathUrls []pathUrlJson) (map[string]entry, error) {
+	configs := make([]entryConfig, len(pathUrls))
+	for i, pu := range pathUrls {
+		configs[i] = entryConfig{
+			Path:     pu.Path,
+			Url:      pu.Url,
+			Expires:  pu.Expires,
+			Methods:  pu.Methods,
+			Query:    pu.Query,
+			Alias:    pu.Alias,
+			Gone:     pu.Gone,
+			Message:  pu.Message,
+			Disabled: pu.Disabled,
+		}
+		for _, d := range pu.Destinations {
+			configs[i].Destinations = append(configs[i].Destinations, weightedUrl(d))
+		}
+		for _, a := range pu.UserAgents {
+			configs[i].Agents = append(configs[i].Agents, agentConfig(a))
+		}
+	}
+	return buildEntries(configs)
+}
+
+func buildMapToml(pathUrls []pathUrlToml) map[string]string {
 	pathToUrls := make(map[string]string)
 	for _, pu := range pathUrls {
-		pathToUrls[pu.Path] = pu.Url
+		pathToUrls[normalizePath(pu.Path)] = pu.Url
 	}
 	return pathToUrls
 }
 
-func buildMapJson(pathUrls []pathUrlJson) map[string]string {
+func buildMapCsv(pathUrls []pathUrlCsv) map[string]string {
 	pathToUrls := make(map[string]string)
 	for _, pu := range pathUrls {
-		pathToUrls[pu.Path] = pu.Url
+		pathToUrls[normalizePath(pu.Path)] = pu.Url
 	}
 	return pathToUrls
 }
 
+func buildMapXml(pathUrls []pathUrlXml) map[string]string {
+	pathToUrls := make(map[string]string)
+	for _, pu := range pathUrls {
+		pathToUrls[normalizePath(pu.Path)] = pu.Url
+	}
+	return pathToUrls
+}
+
+func buildMapIni(pathUrls []pathUrlIni) map[string]string {
+	pathToUrls := make(map[string]string)
+	for _, pu := range pathUrls {
+		pathToUrls[normalizePath(pu.Path)] = pu.Url
+	}
+	return pathToUrls
+}
+
+// configYaml is the mapping form of a YAML config. The plain
+// list form only fills Redirects.
+type configYaml struct {
+	Fallback    string        `yaml:"fallback"`
+	IgnoreQuery []string      `yaml:"ignore_query"`
+	Redirects   []pathUrlYaml `yaml:"redirects"`
+}
+
 type pathUrlYaml struct {
-	Path string `yaml:"path"`
-	Url  string `yaml:"url"`
+	Path         string            `yaml:"path"`
+	Url          string            `yaml:"url"`
+	Status       int               `yaml:"status"`
+	Expires      string            `yaml:"expires"`
+	Destinations []destinationYaml `yaml:"destinations"`
+	Methods      []string          `yaml:"methods"`
+	Query        map[string]string `yaml:"query"`
+	Alias        string            `yaml:"alias"`
+	UserAgents   []userAgentYaml   `yaml:"user_agents"`
+	Gone         bool              `yaml:"gone"`
+	Message      string            `yaml:"message"`
+	Disabled     bool              `yaml:"disabled"`
+}
+
+type destinationYaml struct {
+	Url    string  `yaml:"url"`
+	Weight float64 `yaml:"weight"`
+}
+
+type userAgentYaml struct {
+	Contains string `yaml:"contains"`
+	Regex    string `yaml:"regex"`
+	Url      string `yaml:"url"`
+}
+
+// configJson is the object form of a JSON config with a
+// redirects key. The other forms only fill Redirects.
+type configJson struct {
+	Fallback    string        `json:"fallback"`
+	IgnoreQuery []string      `json:"ignore_query"`
+	Redirects   []pathUrlJson `json:"redirects"`
 }
 
 type pathUrlJson struct {
-	Path string `json:"path"`
-	Url  string `json:"url"`
+	Path         string            `json:"path"`
+	Url          string            `json:"url"`
+	Expires      string            `json:"expires"`
+	Destinations []destinationJson `json:"destinations"`
+	Methods      []string          `json:"methods"`
+	Query        map[string]string `json:"query"`
+	Alias        string            `json:"alias"`
+	UserAgents   []userAgentJson   `json:"user_agents"`
+	Gone         bool              `json:"gone"`
+	Message      string            `json:"message"`
+	Disabled     bool              `json:"disabled"`
+}
+
+type destinationJson struct {
+	Url    string  `json:"url"`
+	Weight float64 `json:"weight"`
+}
+
+type userAgentJson struct {
+	Contains string `json:"contains"`
+	Regex    string `json:"regex"`
+	Url      string `json:"url"`
+}
+
+type pathUrlToml struct {
+	Path string `toml:"path"`
+	Url  string `toml:"url"`
+}
+
+type pathUrlCsv struct {
+	Path string
+	Url  string
+}
+
+type pathUrlXml struct {
+	Path     string `xml:"path"`
+	Url      string `xml:"url"`
+	PathAttr string `xml:"path,attr"`
+	UrlAttr  string `xml:"url,attr"`
+}
+
+type pathUrlIni struct {
+	Path string
+	Url  string
 }
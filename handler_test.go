@@ -0,0 +1,820 @@
+package urlshort
+
+import (
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// get serves a GET request for target with h and returns the
+// recorded response.
+func get(h http.Handler, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func TestMapHandlerWithStatus(t *testing.T) {
+	tests := []struct {
+		status, want int
+	}{
+		{http.StatusMovedPermanently, http.StatusMovedPermanently},
+		{http.StatusTemporaryRedirect, http.StatusTemporaryRedirect},
+		{http.StatusPermanentRedirect, http.StatusPermanentRedirect},
+		{0, http.StatusFound},
+		{http.StatusOK, http.StatusFound},
+		{http.StatusNotFound, http.StatusFound},
+	}
+	for _, tt := range tests {
+		h := MapHandlerWithStatus(map[string]string{"/a": "https://example.com/"}, tt.status, http.NotFoundHandler())
+
+		rec := get(h, "/a")
+		if rec.Code != tt.want {
+			t.Errorf("status %d: got code %d, want %d", tt.status, rec.Code, tt.want)
+		}
+		if got := rec.Header().Get("Location"); got != "https://example.com/" {
+			t.Errorf("status %d: got Location %q", tt.status, got)
+		}
+		if rec := get(h, "/b"); rec.Code != http.StatusNotFound {
+			t.Errorf("status %d: unmapped path got code %d, want fallback", tt.status, rec.Code)
+		}
+	}
+}
+
+func TestTOMLHandler(t *testing.T) {
+	tml := `
+[[paths]]
+path = "/urlshort"
+url = "https://github.com/gophercises/urlshort"
+
+[[paths]]
+path = "/urlshort-final"
+url = "https://github.com/gophercises/urlshort/tree/solution"
+`
+	h, err := TOMLHandler([]byte(tml), http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"/urlshort":       "https://github.com/gophercises/urlshort",
+		"/urlshort-final": "https://github.com/gophercises/urlshort/tree/solution",
+	} {
+		rec := get(h, path)
+		if rec.Code != http.StatusFound || rec.Header().Get("Location") != want {
+			t.Errorf("%s: got %d %q, want 302 %q", path, rec.Code, rec.Header().Get("Location"), want)
+		}
+	}
+	if rec := get(h, "/other"); rec.Code != http.StatusNotFound {
+		t.Errorf("/other: got code %d, want fallback", rec.Code)
+	}
+}
+
+func TestTOMLHandlerEmpty(t *testing.T) {
+	h, err := TOMLHandler(nil, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := get(h, "/a"); rec.Code != http.StatusNotFound {
+		t.Errorf("got code %d, want fallback", rec.Code)
+	}
+}
+
+func TestTOMLHandlerInvalid(t *testing.T) {
+	if _, err := TOMLHandler([]byte("[[paths"), http.NotFoundHandler()); err == nil {
+		t.Error("malformed TOML accepted")
+	}
+}
+
+func TestCSVHandler(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+	}{
+		{"header", "path,url\n/a,\"https://example.com/?x=1,2\"\n"},
+		{"swapped header", "url,path\n\"https://example.com/?x=1,2\",/a\n"},
+		{"no header", "/a,\"https://example.com/?x=1,2\"\n\n"},
+	}
+	for _, tt := range tests {
+		h, err := CSVHandler([]byte(tt.csv), http.NotFoundHandler())
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := get(h, "/a").Header().Get("Location"); got != "https://example.com/?x=1,2" {
+			t.Errorf("%s: got Location %q", tt.name, got)
+		}
+		if rec := get(h, "/path"); rec.Code != http.StatusNotFound {
+			t.Errorf("%s: header row was mapped", tt.name)
+		}
+	}
+}
+
+func TestCSVHandlerInvalid(t *testing.T) {
+	for _, csv := range []string{
+		"path,url\n/a,https://example.com,extra\n",
+		"path,url\n/a\n",
+		"path,url\n/a,\"unterminated\n",
+	} {
+		if _, err := CSVHandler([]byte(csv), http.NotFoundHandler()); err == nil {
+			t.Errorf("%q: accepted", csv)
+		}
+	}
+}
+
+func TestPreserveQuery(t *testing.T) {
+	h := MapHandlerWithOptions(map[string]string{
+		"/a": "https://example.com/landing?ref=a#top",
+		"/b": "https://example.com/",
+	}, Options{PreserveQuery: true}, http.NotFoundHandler())
+
+	tests := []struct {
+		target, want string
+	}{
+		{"/a?utm=news", "https://example.com/landing?ref=a&utm=news#top"},
+		{"/a?ref=b", "https://example.com/landing?ref=a&ref=b#top"},
+		{"/b?utm=news", "https://example.com/?utm=news"},
+		{"/b", "https://example.com/"},
+	}
+	for _, tt := range tests {
+		if got := get(h, tt.target).Header().Get("Location"); got != tt.want {
+			t.Errorf("%s: got Location %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestPreserveQueryOff(t *testing.T) {
+	h := MapHandlerWithOptions(map[string]string{"/b": "https://example.com/"}, Options{}, http.NotFoundHandler())
+	if got := get(h, "/b?utm=news").Header().Get("Location"); got != "https://example.com/" {
+		t.Errorf("got Location %q, want the query dropped", got)
+	}
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	h := MapHandlerWithOptions(map[string]string{
+		"/GitHub": "https://github.com/a",
+		"/github": "https://github.com/b",
+		"/Docs":   "https://docs.example.com/",
+	}, Options{CaseInsensitive: true}, http.NotFoundHandler())
+
+	tests := []struct {
+		target, want string
+	}{
+		{"/GitHub", "https://github.com/a"},
+		{"/github", "https://github.com/b"},
+		{"/GITHUB", "https://github.com/a"},
+		{"/docs", "https://docs.example.com/"},
+		{"/DOCS", "https://docs.example.com/"},
+		{"/other", ""},
+	}
+	for _, tt := range tests {
+		if got := get(h, tt.target).Header().Get("Location"); got != tt.want {
+			t.Errorf("%s: got Location %q, want %q", tt.target, got, tt.want)
+		}
+	}
+
+	strict := MapHandler(map[string]string{"/Docs": "https://docs.example.com/"}, http.NotFoundHandler())
+	if rec := get(strict, "/docs"); rec.Code != http.StatusNotFound {
+		t.Errorf("MapHandler matched /docs to /Docs")
+	}
+}
+
+func TestXMLHandler(t *testing.T) {
+	xml := `<redirects>
+  <redirect>
+    <path>/a</path>
+    <url>https://example.com/a?x=1&amp;y=2</url>
+  </redirect>
+  <redirect path="/b" url="https://example.com/b"/>
+</redirects>`
+	h, err := XMLHandler([]byte(xml), http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"/a": "https://example.com/a?x=1&y=2",
+		"/b": "https://example.com/b",
+	} {
+		if got := get(h, path).Header().Get("Location"); got != want {
+			t.Errorf("%s: got Location %q, want %q", path, got, want)
+		}
+	}
+	if rec := get(h, "/c"); rec.Code != http.StatusNotFound {
+		t.Errorf("/c: got code %d, want fallback", rec.Code)
+	}
+}
+
+func TestXMLHandlerInvalid(t *testing.T) {
+	for _, xml := range []string{
+		`<redirects><redirect>`,
+		`<redirects><redirect path="/a"/></redirects>`,
+	} {
+		if _, err := XMLHandler([]byte(xml), http.NotFoundHandler()); err == nil {
+			t.Errorf("%s: accepted", xml)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	want := map[string]string{
+		"/a": "https://example.com/a",
+		"/b": "https://example.com/b",
+	}
+	tests := []struct {
+		name  string
+		parse func([]byte) (map[string]string, error)
+		data  string
+	}{
+		{"YAML", ParseYAML, "- path: /a\n  url: https://example.com/a\n- path: /b\n  url: https://example.com/b\n"},
+		{"JSON", ParseJSON, `[{"path": "/a", "url": "https://example.com/a"}, {"path": "/b", "url": "https://example.com/b"}]`},
+		{"TOML", ParseTOML, "[[paths]]\npath = \"/a\"\nurl = \"https://example.com/a\"\n[[paths]]\npath = \"/b\"\nurl = \"https://example.com/b\"\n"},
+		{"CSV", ParseCSV, "path,url\n/a,https://example.com/a\n/b,https://example.com/b\n"},
+		{"XML", ParseXML, `<r><e path="/a" url="https://example.com/a"/><e path="/b" url="https://example.com/b"/></r>`},
+	}
+	for _, tt := range tests {
+		got, err := tt.parse([]byte(tt.data))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !maps.Equal(got, want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, want)
+		}
+	}
+}
+
+func TestParseResultIsEditable(t *testing.T) {
+	pathsToUrls, err := ParseYAML([]byte("- path: /a\n  url: https://example.com/a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pathsToUrls["/b"] = "https://example.com/b"
+	h := MapHandler(pathsToUrls, http.NotFoundHandler())
+	if rec := get(h, "/b"); rec.Code != http.StatusFound {
+		t.Errorf("/b: got code %d, want 302", rec.Code)
+	}
+}
+
+func TestParseOptionsDisallowDuplicates(t *testing.T) {
+	yml := []byte("- path: /foo\n  url: https://example.com/1\n- path: /foo\n  url: https://example.com/2\n")
+	m, err := ParseYAMLWithOptions(yml, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["/foo"] != "https://example.com/2" {
+		t.Errorf("without the option: got %q, want the last entry", m["/foo"])
+	}
+
+	_, err = ParseYAMLWithOptions(yml, ParseOptions{DisallowDuplicates: true})
+	if err == nil || !strings.Contains(err.Error(), `"/foo"`) {
+		t.Errorf("YAML: got error %v, want one naming /foo", err)
+	}
+
+	jsn := []byte(`[{"path": "/foo", "url": "https://example.com/1"}, {"path": "foo", "url": "https://example.com/2"}]`)
+	_, err = ParseJSONWithOptions(jsn, ParseOptions{DisallowDuplicates: true})
+	if err == nil || !strings.Contains(err.Error(), `"/foo"`) {
+		t.Errorf("JSON: got error %v, want one naming /foo", err)
+	}
+}
+
+func TestEmptyURLRejected(t *testing.T) {
+	tests := []struct {
+		name string
+		h    func([]byte, http.Handler) (http.HandlerFunc, error)
+		data string
+	}{
+		{"YAML", YAMLHandler, "- path: /a\n  url: ''\n"},
+		{"YAML blank", YAMLHandler, "- path: /a\n  url: '  '\n"},
+		{"YAML missing", YAMLHandler, "- path: /a\n"},
+		{"JSON", JSONHandler, `[{"path": "/a", "url": ""}]`},
+		{"TOML", TOMLHandler, "[[paths]]\npath = \"/a\"\nurl = \"\"\n"},
+		{"CSV", CSVHandler, "path,url\n/a,\n"},
+		{"XML", XMLHandler, `<r><e path="/a" url=""/></r>`},
+	}
+	for _, tt := range tests {
+		_, err := tt.h([]byte(tt.data), http.NotFoundHandler())
+		if err == nil || !strings.Contains(err.Error(), "empty url") {
+			t.Errorf("%s: got error %v, want an empty url error", tt.name, err)
+		}
+	}
+}
+
+func TestRelativeURLAccepted(t *testing.T) {
+	h, err := YAMLHandler([]byte("- path: /a\n  url: /b\n"), http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := get(h, "/a").Header().Get("Location"); got != "/b" {
+		t.Errorf("got Location %q, want /b", got)
+	}
+}
+
+func TestJSONHandlerFlatObject(t *testing.T) {
+	h, err := JSONHandler([]byte(` {"/a": "https://example.com/a", "b": "https://example.com/b"}`), http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"/a": "https://example.com/a",
+		"/b": "https://example.com/b",
+	} {
+		if got := get(h, path).Header().Get("Location"); got != want {
+			t.Errorf("%s: got Location %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestJSONHandlerFlatObjectInvalid(t *testing.T) {
+	for _, jsn := range []string{
+		`{"/a": {"url": "https://example.com/a"}}`,
+		`{"/a": 1}`,
+		`"/a"`,
+	} {
+		if _, err := JSONHandler([]byte(jsn), http.NotFoundHandler()); err == nil {
+			t.Errorf("%s: accepted", jsn)
+		}
+	}
+}
+
+func TestJSONHandlerFlatObjectDuplicates(t *testing.T) {
+	jsn := []byte(`{"/a": "https://example.com/x", "/a": "https://example.com/y"}`)
+	_, err := ParseJSONWithOptions(jsn, ParseOptions{DisallowDuplicates: true})
+	if err == nil || !strings.Contains(err.Error(), `duplicate path "/a"`) {
+		t.Errorf("got error %v, want one naming /a", err)
+	}
+	if _, err := JSONHandler(jsn, http.NotFoundHandler()); err != nil {
+		t.Errorf("lenient parse: %v", err)
+	}
+}
+
+func TestQueryParams(t *testing.T) {
+	h := MapHandlerWithOptions(map[string]string{
+		"/a": "https://example.com/x?ref=own&k=1",
+		"/b": "https://example.com/",
+	}, Options{QueryParams: map[string]string{"ref": "short ener", "c": "&"}}, http.NotFoundHandler())
+
+	tests := []struct {
+		target, want string
+	}{
+		{"/a", "https://example.com/x?ref=own&k=1&c=%26"},
+		{"/b", "https://example.com/?c=%26&ref=short+ener"},
+	}
+	for _, tt := range tests {
+		if got := get(h, tt.target).Header().Get("Location"); got != tt.want {
+			t.Errorf("%s: got Location %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestINIHandler(t *testing.T) {
+	ini := `; a comment before any section
+[other]
+/x = https://example.com/x
+
+[redirects]
+# another comment
+/a = https://example.com/a?q=1
+b=https://example.com/b
+`
+	h, err := INIHandler([]byte(ini), http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"/a": "https://example.com/a?q=1",
+		"/b": "https://example.com/b",
+		"/x": "",
+	} {
+		if got := get(h, path).Header().Get("Location"); got != want {
+			t.Errorf("%s: got Location %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestINIHandlerInvalid(t *testing.T) {
+	for _, ini := range []string{
+		"[redirects]\nno equals sign\n",
+		"[redirects]\n/a =\n",
+	} {
+		if _, err := INIHandler([]byte(ini), http.NotFoundHandler()); err == nil {
+			t.Errorf("%q: accepted", ini)
+		}
+	}
+}
+
+func TestBeforeRedirect(t *testing.T) {
+	var called []string
+	h := MapHandlerWithOptions(map[string]string{
+		"/a": "https://example.com/a",
+		"/b": "https://example.com/b",
+	}, Options{BeforeRedirect: func(r *http.Request, dest string) (string, bool) {
+		called = append(called, r.URL.Path)
+		if r.URL.Path == "/b" {
+			return "", false
+		}
+		return dest + "?region=eu", true
+	}}, http.NotFoundHandler())
+
+	if got := get(h, "/a").Header().Get("Location"); got != "https://example.com/a?region=eu" {
+		t.Errorf("/a: got Location %q, want the rewritten destination", got)
+	}
+	if rec := get(h, "/b"); rec.Code != http.StatusNotFound {
+		t.Errorf("/b: got code %d, want fallback", rec.Code)
+	}
+	if rec := get(h, "/c"); rec.Code != http.StatusNotFound {
+		t.Errorf("/c: got code %d, want fallback", rec.Code)
+	}
+	if len(called) != 2 {
+		t.Errorf("BeforeRedirect called for %v, want /a and /b only", called)
+	}
+}
+
+func TestCacheMaxAge(t *testing.T) {
+	m := map[string]string{"/a": "https://example.com/a"}
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"permanent", Options{Status: http.StatusMovedPermanently, CacheMaxAge: time.Hour}, "public, max-age=3600"},
+		{"permanent 308", Options{Status: http.StatusPermanentRedirect, CacheMaxAge: time.Minute}, "public, max-age=60"},
+		{"temporary", Options{CacheMaxAge: time.Hour}, "no-cache"},
+		{"unset", Options{Status: http.StatusMovedPermanently}, ""},
+	}
+	for _, tt := range tests {
+		h := MapHandlerWithOptions(m, tt.opts, http.NotFoundHandler())
+		if got := get(h, "/a").Header().Get("Cache-Control"); got != tt.want {
+			t.Errorf("%s: got Cache-Control %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	h := MapHandlerWithOptions(m, Options{CacheMaxAge: time.Hour}, http.NotFoundHandler())
+	if got := get(h, "/b").Header().Get("Cache-Control"); got != "" {
+		t.Errorf("fallback: got Cache-Control %q, want none", got)
+	}
+}
+
+func TestPathsWithoutLeadingSlash(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func() (http.HandlerFunc, error)
+	}{
+		{"yaml", func() (http.HandlerFunc, error) {
+			return YAMLHandler([]byte("- path: gh\n  url: https://example.com/gh\n- path: /docs\n  url: https://example.com/docs\n"), http.NotFoundHandler())
+		}},
+		{"json", func() (http.HandlerFunc, error) {
+			return JSONHandler([]byte(`[{"path":"gh","url":"https://example.com/gh"},{"path":"/docs","url":"https://example.com/docs"}]`), http.NotFoundHandler())
+		}},
+		{"flat json", func() (http.HandlerFunc, error) {
+			return JSONHandler([]byte(`{"gh":"https://example.com/gh","/docs":"https://example.com/docs"}`), http.NotFoundHandler())
+		}},
+		{"toml", func() (http.HandlerFunc, error) {
+			return TOMLHandler([]byte("[[paths]]\npath = \"gh\"\nurl = \"https://example.com/gh\"\n[[paths]]\npath = \"/docs\"\nurl = \"https://example.com/docs\"\n"), http.NotFoundHandler())
+		}},
+		{"csv", func() (http.HandlerFunc, error) {
+			return CSVHandler([]byte("gh,https://example.com/gh\n/docs,https://example.com/docs\n"), http.NotFoundHandler())
+		}},
+	}
+	for _, tt := range tests {
+		h, err := tt.build()
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		for path, want := range map[string]string{
+			"/gh":   "https://example.com/gh",
+			"/docs": "https://example.com/docs",
+		} {
+			if got := get(h, path).Header().Get("Location"); got != want {
+				t.Errorf("%s %s: got Location %q, want %q", tt.name, path, got, want)
+			}
+		}
+	}
+}
+
+func TestLinkBody(t *testing.T) {
+	m := map[string]string{"/a": "https://example.com/a?x=1&y=2"}
+	h := MapHandlerWithOptions(m, Options{LinkBody: true}, http.NotFoundHandler())
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodGet} {
+		rec := httptest.NewRecorder()
+		h(rec, httptest.NewRequest(method, "/a", nil))
+		if rec.Code != http.StatusFound {
+			t.Errorf("%s: got code %d, want 302", method, rec.Code)
+		}
+		if want := `<a href="https://example.com/a?x=1&amp;y=2">Found</a>`; !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("%s: body %q does not contain %q", method, rec.Body, want)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+			t.Errorf("%s: got Content-Type %q, want text/html", method, ct)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	MapHandler(m, http.NotFoundHandler())(rec, httptest.NewRequest(http.MethodPost, "/a", nil))
+	if rec.Body.Len() != 0 {
+		t.Errorf("POST without LinkBody: got body %q, want none", rec.Body)
+	}
+}
+
+func TestRootPath(t *testing.T) {
+	h, err := YAMLHandler([]byte("- path: /\n  url: https://example.com/\n- path: /home\n  url: /\n"), http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"/":     "https://example.com/",
+		"/home": "/",
+	} {
+		if got := get(h, path).Header().Get("Location"); got != want {
+			t.Errorf("%s: got Location %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestRedirectToSelfRejected(t *testing.T) {
+	for _, dest := range []string{"/", "./", ".", "/?x=1", "#top"} {
+		yml := "- path: /\n  url: \"" + dest + "\"\n"
+		if _, err := YAMLHandler([]byte(yml), http.NotFoundHandler()); err == nil {
+			t.Errorf("/ to %q: accepted", dest)
+		}
+	}
+	if _, err := JSONHandler([]byte(`[{"path": "/a", "url": "./a"}]`), http.NotFoundHandler()); err == nil {
+		t.Errorf(`/a to "./a": accepted`)
+	}
+}
+
+func TestJSONResponse(t *testing.T) {
+	h := MapHandlerWithOptions(map[string]string{"/a": "https://example.com/a"}, Options{
+		JSONResponse: true,
+		QueryParams:  map[string]string{"ref": "short"},
+	}, http.NotFoundHandler())
+	serve := func(accept string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/a", nil)
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		h(rec, r)
+		return rec
+	}
+
+	rec := serve("application/json")
+	if rec.Code != http.StatusOK {
+		t.Errorf("JSON: got code %d, want 200", rec.Code)
+	}
+	if want := "{\"path\":\"/a\",\"url\":\"https://example.com/a?ref=short\"}\n"; rec.Body.String() != want {
+		t.Errorf("JSON: got body %q, want %q", rec.Body, want)
+	}
+	if rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("JSON: got Content-Type %q", rec.Header().Get("Content-Type"))
+	}
+
+	tests := []struct {
+		accept string
+		code   int
+	}{
+		{"text/html;q=0.5, application/json", http.StatusOK},
+		{"", http.StatusFound},
+		{"*/*", http.StatusFound},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", http.StatusFound},
+		{"text/html, application/json", http.StatusFound},
+		{"application/json;q=0.5, text/html;q=0.9", http.StatusFound},
+	}
+	for _, tt := range tests {
+		rec := serve(tt.accept)
+		if rec.Code != tt.code {
+			t.Errorf("Accept %q: got code %d, want %d", tt.accept, rec.Code, tt.code)
+		}
+		if rec.Header().Get("Vary") != "Accept" {
+			t.Errorf("Accept %q: got Vary %q, want Accept", tt.accept, rec.Header().Get("Vary"))
+		}
+	}
+}
+
+// fuzzConfig checks that parse and handler never panic on
+// data, that handler never returns a nil handler with a nil
+// error, and that a handler it does return can serve requests.
+func fuzzConfig(t *testing.T, data []byte, parse func([]byte) (map[string]string, error), handler func([]byte, http.Handler) (http.HandlerFunc, error)) {
+	if m, err := parse(data); err == nil && m == nil {
+		t.Errorf("nil map with a nil error")
+	}
+	h, err := handler(data, http.NotFoundHandler())
+	if err != nil {
+		return
+	}
+	if h == nil {
+		t.Fatal("nil handler with a nil error")
+	}
+	for _, target := range []string{"/", "/a", "/a?q=1", "/gh"} {
+		get(h, target)
+	}
+}
+
+func FuzzParseYAML(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		"- path: /a\n  url: https://example.com/a\n",
+		"- path: /a\n  url: https://example.com/a\n  query: {q: x}\n",
+		"- path: /gh\n  alias: /a\n- path: /a\n  url: https://example.com/a\n",
+		"- path: /a\n  destinations: [{url: https://example.com/a, weight: .nan}]\n",
+		"redirects:\n  - path: /a\n    url: https://example.com/a\nfallback: https://example.com/\n",
+		"[[[[[[[[",
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzConfig(t, data, ParseYAML, YAMLHandler)
+	})
+}
+
+func FuzzParseJSON(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		"null",
+		`[{"path": "/a", "url": "https://example.com/a"}]`,
+		`[{"path": "/a", "destinations": [{"url": "https://example.com/a", "weight": 1}]}]`,
+		`{"/a": "https://example.com/a"}`,
+		`{"redirects": [{"path": "/a", "url": "https://example.com/a"}], "ignore_query": ["utm_source"]}`,
+		`[{"path": "/a", "alias": "/b"}, {"path": "/b", "alias": "/a"}]`,
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzConfig(t, data, ParseJSON, JSONHandler)
+	})
+}
+
+func TestUpgradeHTTPS(t *testing.T) {
+	m := map[string]string{
+		"/default-port": "http://example.com:80/x?q=1",
+		"/https":        "https://example.com/",
+		"/relative":     "/elsewhere",
+		"/other":        "http://other.example/",
+		"/upper":        "HTTP://example.com:8080/",
+	}
+	tests := []struct {
+		name  string
+		hosts []string
+		want  map[string]string
+	}{
+		{"all hosts", nil, map[string]string{
+			"/default-port": "https://example.com/x?q=1",
+			"/https":        "https://example.com/",
+			"/relative":     "/elsewhere",
+			"/other":        "https://other.example/",
+			"/upper":        "https://example.com:8080/",
+		}},
+		{"listed hosts", []string{"example.com"}, map[string]string{
+			"/default-port": "https://example.com/x?q=1",
+			"/other":        "http://other.example/",
+		}},
+	}
+	for _, tt := range tests {
+		h := MapHandlerWithOptions(m, Options{UpgradeHTTPS: true, UpgradeHosts: tt.hosts}, http.NotFoundHandler())
+		for path, want := range tt.want {
+			if got := get(h, path).Header().Get("Location"); got != want {
+				t.Errorf("%s %s: got Location %q, want %q", tt.name, path, got, want)
+			}
+		}
+	}
+
+	if got := get(MapHandler(m, http.NotFoundHandler()), "/other").Header().Get("Location"); got != "http://other.example/" {
+		t.Errorf("without UpgradeHTTPS: got Location %q, want it unchanged", got)
+	}
+}
+
+func TestDefaultScheme(t *testing.T) {
+	tests := []struct {
+		dest, want string
+	}{
+		{"example.com/foo", "https://example.com/foo"},
+		{"example.com", "https://example.com"},
+		{"localhost:8080/x", "https://localhost:8080/x"},
+		{"sub.example.com?q=", "https://sub.example.com?q="},
+		{"https://example.com", "https://example.com"},
+		{"/relative", "/relative"},
+		{"./page.html", "./page.html"},
+		{"docs/page", "docs/page"},
+		{"mailto:someone@example.com", "mailto:someone@example.com"},
+		{"example.com:/x", "example.com:/x"},
+		{"someone@example.com", "someone@example.com"},
+	}
+	m := make(map[string]string)
+	for _, tt := range tests {
+		m[tt.dest] = tt.dest
+	}
+	got := withDefaultScheme(m, "https")
+	for _, tt := range tests {
+		if got[tt.dest] != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.dest, got[tt.dest], tt.want)
+		}
+	}
+
+	h := MapHandlerWithOptions(map[string]string{"/a": "example.com/foo"}, Options{DefaultScheme: "http"}, http.NotFoundHandler())
+	if got := get(h, "/a").Header().Get("Location"); got != "http://example.com/foo" {
+		t.Errorf("handler: got Location %q, want http://example.com/foo", got)
+	}
+}
+
+func TestConfigFallback(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func([]byte, http.Handler) (http.HandlerFunc, error)
+		config  string
+	}{
+		{"YAML", YAMLHandler, "fallback: https://example.com/home\nredirects:\n  - path: /a\n    url: https://example.com/a\n"},
+		{"JSON", JSONHandler, `{"fallback": "https://example.com/home", "redirects": [{"path": "/a", "url": "https://example.com/a"}]}`},
+	}
+	for _, tt := range tests {
+		h, err := tt.handler([]byte(tt.config), http.NotFoundHandler())
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		for path, want := range map[string]string{
+			"/a":     "https://example.com/a",
+			"/other": "https://example.com/home",
+		} {
+			if got := get(h, path).Header().Get("Location"); got != want {
+				t.Errorf("%s %s: got Location %q, want %q", tt.name, path, got, want)
+			}
+		}
+	}
+
+	h, err := YAMLHandler([]byte("redirects:\n  - path: /a\n    url: https://example.com/a\n"), http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := get(h, "/other"); rec.Code != http.StatusNotFound {
+		t.Errorf("no fallback url: got code %d, want the fallback handler", rec.Code)
+	}
+
+	// The reserved keys are not taken for paths in a flat object.
+	for _, jsn := range []string{
+		`{"/a": "https://example.com/a", "fallback": "https://example.com/f"}`,
+		`{"/a": "https://example.com/a", "ignore_query": ["utm_*"]}`,
+	} {
+		_, err := JSONHandler([]byte(jsn), http.NotFoundHandler())
+		if err == nil || !strings.Contains(err.Error(), `no "redirects" list`) {
+			t.Errorf("%s: got error %v, want one asking for a redirects list", jsn, err)
+		}
+	}
+}
+
+func TestConfigFallbackParse(t *testing.T) {
+	m, err := ParseYAML([]byte("fallback: https://example.com/home\nredirects: [{path: /a, url: https://example.com/a}]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"/a": "https://example.com/a"}; !maps.Equal(m, want) {
+		t.Errorf("got %v, want %v", m, want)
+	}
+	if _, err := YAMLHandler([]byte("just a string"), http.NotFoundHandler()); err == nil {
+		t.Errorf("scalar YAML accepted")
+	}
+	if _, err := YAMLHandler(nil, http.NotFoundHandler()); err != nil {
+		t.Errorf("empty YAML: %v", err)
+	}
+}
+
+func TestDisallowUnknownFields(t *testing.T) {
+	strict := ParseOptions{DisallowUnknownFields: true}
+	parsers := map[string]func([]byte, ParseOptions) (map[string]string, error){
+		"yaml": ParseYAMLWithOptions,
+		"json": ParseJSONWithOptions,
+	}
+	tests := []struct {
+		format, name, data string
+	}{
+		{"yaml", "typo", "- path: /a\n  ur: https://example.com/x\n  url: https://example.com/a\n"},
+		{"yaml", "top-level field", "fallback: https://example.com/\nowner: me\nredirects:\n  - path: /a\n    url: https://example.com/a\n"},
+		{"json", "typo", `[{"path": "/a", "ur": "https://example.com/x", "url": "https://example.com/a"}]`},
+		{"json", "top-level field", `{"owner": "me", "redirects": [{"path": "/a", "url": "https://example.com/a"}]}`},
+	}
+	for _, tt := range tests {
+		parse := parsers[tt.format]
+		m, err := parse([]byte(tt.data), ParseOptions{})
+		if err != nil || m["/a"] != "https://example.com/a" {
+			t.Errorf("%s %s: lenient parse got %v, %v", tt.format, tt.name, m, err)
+		}
+		if _, err := parse([]byte(tt.data), strict); err == nil {
+			t.Errorf("%s %s: accepted in strict mode", tt.format, tt.name)
+		}
+	}
+
+	for format, data := range map[string]string{
+		"yaml":      "- path: /a\n  url: https://example.com/a\n",
+		"json":      `[{"path": "/a", "url": "https://example.com/a"}]`,
+		"json flat": `{"/a": "https://example.com/a"}`,
+		"json list": `{"redirects": [{"path": "/a", "url": "https://example.com/a"}]}`,
+	} {
+		m, err := parsers[strings.Fields(format)[0]]([]byte(data), strict)
+		if err != nil || m["/a"] != "https://example.com/a" {
+			t.Errorf("%s: strict parse got %v, %v", format, m, err)
+		}
+	}
+	if _, err := ParseJSONWithOptions([]byte(`[{"path": "/a", "url": "https://example.com/a"}] x`), strict); err == nil {
+		t.Errorf("json: trailing data accepted in strict mode")
+	}
+}
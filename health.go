@@ -0,0 +1,31 @@
+package urlshort
+
+import (
+	"io"
+	"net/http"
+)
+
+// LoadStatusReporter is implemented by handlers that load
+// their mapping from an external source, such as urlshortfile.Watcher,
+// to report whether the most recent load succeeded.
+type LoadStatusReporter interface {
+	// LastLoadError returns the error of the most recent load,
+	// or nil if it succeeded.
+	LastLoadError() error
+}
+
+// HealthHandler returns an http.Handler for readiness probes.
+// It responds with http.StatusOK while the last load of h
+// succeeded, and with http.StatusServiceUnavailable and the
+// load error as a plain text body otherwise.
+func HealthHandler(h LoadStatusReporter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := h.LastLoadError(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, "ok\n")
+	})
+}
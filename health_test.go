@@ -0,0 +1,28 @@
+package urlshort
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// loadStatus is a LoadStatusReporter reporting a fixed error.
+type loadStatus struct{ err error }
+
+func (s loadStatus) LastLoadError() error { return s.err }
+
+func TestHealthHandler(t *testing.T) {
+	rec := get(HealthHandler(loadStatus{}), "/healthz")
+	if rec.Code != http.StatusOK || rec.Body.String() != "ok\n" {
+		t.Errorf("healthy: got %d %q, want 200 %q", rec.Code, rec.Body, "ok\n")
+	}
+
+	rec = get(HealthHandler(loadStatus{errors.New("bad yaml")}), "/healthz")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("unhealthy: got code %d, want 503", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "bad yaml") {
+		t.Errorf("unhealthy: body %q does not contain the load error", rec.Body)
+	}
+}
@@ -0,0 +1,103 @@
+package urlshort
+
+import (
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// HostHandler will return an http.HandlerFunc (which also
+// implements http.Handler) that routes on the request host as
+// well as the path. hostsToPaths maps a host name, such as
+// "go.acme.com", to the paths and URLs served for that host.
+// Any port in the request host is ignored and host names are
+// matched case-insensitively. If the host or the path is not
+// provided in the map, then the fallback http.Handler will be
+// called instead.
+//
+// A host name starting with "*." such as "*.acme.com" is a
+// wildcard matching any subdomain at any depth, like
+// "go.acme.com" or "a.b.acme.com", but not "acme.com" itself.
+// Exact host names take priority, then the longest matching
+// wildcard. In the destinations of a wildcard, "{subdomain}"
+// is replaced by the part of the host it matched, such as
+// "a.b" for "a.b.acme.com".
+func HostHandler(hostsToPaths map[string]map[string]string, fallback http.Handler) http.HandlerFunc {
+	return HostHandlerWithOptions(hostsToPaths, HostOptions{}, fallback)
+}
+
+// HostOptions configures HostHandlerWithOptions.
+type HostOptions struct {
+	// TrustForwardedHost takes the request host from the first
+	// host in the X-Forwarded-Host header when present, instead
+	// of from r.Host. Only enable it behind a proxy that sets
+	// the header, as clients can otherwise pick their own host.
+	TrustForwardedHost bool
+}
+
+// HostHandlerWithOptions works like HostHandler, configured by
+// opts.
+func HostHandlerWithOptions(hostsToPaths map[string]map[string]string, opts HostOptions, fallback http.Handler) http.HandlerFunc {
+	hosts := make(map[string]map[string]string, len(hostsToPaths))
+	var wildcards []hostWildcard
+	for host, pathsToUrls := range hostsToPaths {
+		host = strings.ToLower(host)
+		if suffix, ok := strings.CutPrefix(host, "*."); ok {
+			wildcards = append(wildcards, hostWildcard{"." + suffix, pathsToUrls})
+			continue
+		}
+		hosts[host] = pathsToUrls
+	}
+	sort.Slice(wildcards, func(i, j int) bool {
+		return len(wildcards[i].suffix) > len(wildcards[j].suffix)
+	})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		host := requestHost(r, opts.TrustForwardedHost)
+		if pathsToUrls, ok := hosts[host]; ok {
+			if dest, ok := pathsToUrls[r.URL.Path]; ok {
+				http.Redirect(w, r, dest, http.StatusFound)
+				return
+			}
+		} else {
+			for _, wc := range wildcards {
+				sub, ok := strings.CutSuffix(host, wc.suffix)
+				if !ok || sub == "" {
+					continue
+				}
+				if dest, ok := wc.pathsToUrls[r.URL.Path]; ok {
+					http.Redirect(w, r, strings.ReplaceAll(dest, "{subdomain}", sub), http.StatusFound)
+					return
+				}
+				break
+			}
+		}
+
+		fallback.ServeHTTP(w, r)
+	}
+}
+
+// hostWildcard holds the paths served for the hosts ending in
+// suffix, which includes the leading dot.
+type hostWildcard struct {
+	suffix      string
+	pathsToUrls map[string]string
+}
+
+// requestHost returns the lowercased host of r without port,
+// taken from X-Forwarded-Host if trustForwardedHost is set and
+// the header is present.
+func requestHost(r *http.Request, trustForwardedHost bool) string {
+	host := r.Host
+	if trustForwardedHost {
+		if fwd := r.Header.Get("X-Forwarded-Host"); fwd != "" {
+			first, _, _ := strings.Cut(fwd, ",")
+			host = strings.TrimSpace(first)
+		}
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
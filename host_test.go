@@ -0,0 +1,105 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// getHost serves a GET request for path on host with h and
+// returns the Location of the response.
+func getHost(h http.Handler, host, path string) string {
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	r.Host = host
+	h.ServeHTTP(rec, r)
+	return rec.Header().Get("Location")
+}
+
+func TestHostHandler(t *testing.T) {
+	h := HostHandler(map[string]map[string]string{
+		"go.acme.com": {"/x": "https://acme.com/x"},
+		"go.beta.com": {"/x": "https://beta.com/x", "/y": "https://beta.com/y"},
+	}, http.NotFoundHandler())
+
+	tests := []struct {
+		host, path, want string
+	}{
+		{"go.acme.com", "/x", "https://acme.com/x"},
+		{"go.acme.com:8080", "/x", "https://acme.com/x"},
+		{"GO.Beta.com", "/x", "https://beta.com/x"},
+		{"go.beta.com", "/y", "https://beta.com/y"},
+		{"go.acme.com", "/y", ""},
+		{"other.com", "/x", ""},
+	}
+	for _, tt := range tests {
+		if got := getHost(h, tt.host, tt.path); got != tt.want {
+			t.Errorf("%s%s: got Location %q, want %q", tt.host, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestHostHandlerTrustForwardedHost(t *testing.T) {
+	hosts := map[string]map[string]string{
+		"go.acme.com": {"/a": "https://acme.com/a"},
+		"lb.internal": {"/a": "https://internal.example/a"},
+	}
+	get := func(h http.Handler, forwarded string) string {
+		r := httptest.NewRequest(http.MethodGet, "/a", nil)
+		r.Host = "lb.internal"
+		if forwarded != "" {
+			r.Header.Set("X-Forwarded-Host", forwarded)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec.Header().Get("Location")
+	}
+
+	trusted := HostHandlerWithOptions(hosts, HostOptions{TrustForwardedHost: true}, http.NotFoundHandler())
+	tests := []struct {
+		forwarded, want string
+	}{
+		{"go.acme.com", "https://acme.com/a"},
+		{"GO.acme.com:443, lb.internal", "https://acme.com/a"},
+		{"other.example", ""},
+		{"", "https://internal.example/a"},
+	}
+	for _, tt := range tests {
+		if got := get(trusted, tt.forwarded); got != tt.want {
+			t.Errorf("trusted %q: got Location %q, want %q", tt.forwarded, got, tt.want)
+		}
+	}
+
+	plain := HostHandler(hosts, http.NotFoundHandler())
+	if got := get(plain, "go.acme.com"); got != "https://internal.example/a" {
+		t.Errorf("untrusted: got Location %q, want the one for r.Host", got)
+	}
+}
+
+func TestHostHandlerWildcard(t *testing.T) {
+	h := HostHandler(map[string]map[string]string{
+		"go.acme.com":   {"/a": "https://acme.com/exact"},
+		"*.acme.com":    {"/a": "https://acme.com/{subdomain}", "/w": "https://acme.com/w"},
+		"*.eu.acme.com": {"/a": "https://eu.acme.com/a"},
+	}, http.NotFoundHandler())
+
+	tests := []struct {
+		host, path, want string
+	}{
+		{"go.acme.com", "/a", "https://acme.com/exact"},
+		{"go.acme.com", "/w", ""},
+		{"docs.acme.com", "/a", "https://acme.com/docs"},
+		{"x.y.acme.com:8080", "/a", "https://acme.com/x.y"},
+		{"docs.acme.com", "/w", "https://acme.com/w"},
+		{"fr.eu.acme.com", "/a", "https://eu.acme.com/a"},
+		{"fr.eu.acme.com", "/w", ""},
+		{"acme.com", "/a", ""},
+		{"evilacme.com", "/a", ""},
+		{"other.com", "/a", ""},
+	}
+	for _, tt := range tests {
+		if got := getHost(h, tt.host, tt.path); got != tt.want {
+			t.Errorf("%s%s: got Location %q, want %q", tt.host, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package urlshort
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+)
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<table>
+<tr><th>Path</th><th>Destination</th></tr>
+{{range .Links}}<tr><td>{{.Path}}</td><td><a href="{{.Url}}">{{.Url}}</a></td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+type indexLink struct {
+	Path string
+	Url  string
+}
+
+// IndexHandler returns an http.Handler serving an HTML page
+// titled title that lists every path in pathsToUrls, sorted,
+// with a link to its destination. Mount it at the path the
+// page should live at, such as "/".
+//
+// Paths, urls and the title are escaped, and links to urls
+// with a scheme other than http, https or mailto, such as
+// javascript:, are disabled. The page is rendered once, so
+// later changes to pathsToUrls are not reflected.
+func IndexHandler(pathsToUrls map[string]string, title string) http.Handler {
+	data := struct {
+		Title string
+		Links []indexLink
+	}{Title: title}
+	for _, path := range sortedKeys(pathsToUrls) {
+		data.Links = append(data.Links, indexLink{Path: path, Url: pathsToUrls[path]})
+	}
+
+	var page bytes.Buffer
+	if err := indexTemplate.Execute(&page, data); err != nil {
+		panic("urlshort: rendering index: " + err.Error())
+	}
+	body := page.Bytes()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(body)
+	})
+}
@@ -0,0 +1,49 @@
+package urlshort
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIndexHandler(t *testing.T) {
+	h := IndexHandler(map[string]string{
+		"/b": "https://example.com/b?x=1&y=2",
+		"/a": "https://example.com/a",
+	}, "Links")
+	rec := get(h, "/")
+	if ct := rec.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("got Content-Type %q, want text/html", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"<title>Links</title>",
+		`<tr><td>/a</td><td><a href="https://example.com/a">https://example.com/a</a></td></tr>`,
+		`<tr><td>/b</td><td><a href="https://example.com/b?x=1&amp;y=2">https://example.com/b?x=1&amp;y=2</a></td></tr>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page does not contain %s:\n%s", want, body)
+		}
+	}
+	if strings.Index(body, "<td>/a</td>") > strings.Index(body, "<td>/b</td>") {
+		t.Errorf("entries not sorted by path:\n%s", body)
+	}
+}
+
+func TestIndexHandlerEscapes(t *testing.T) {
+	h := IndexHandler(map[string]string{
+		"/evil":        `"><script>alert(1)</script>`,
+		"/js":          "javascript:alert(1)",
+		"/<b>path</b>": "https://example.com/",
+	}, "My <links>")
+	body := get(h, "/").Body.String()
+	for _, bad := range []string{"<script>", "<b>", `href="javascript:`, "My <links>"} {
+		if strings.Contains(body, bad) {
+			t.Errorf("page contains %s unescaped:\n%s", bad, body)
+		}
+	}
+	for _, want := range []string{"My &lt;links&gt;", "&lt;b&gt;path&lt;/b&gt;", "&lt;script&gt;alert(1)&lt;/script&gt;"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page does not contain %s:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package urlshort
+
+import "net/http"
+
+// KeyedHandler works like MapHandler, but looks requests up in
+// lookup by the key keyFunc derives from them instead of by
+// path. This allows matching on any request attribute, such as
+// the host and path, or a header. MapHandler behaves like
+// KeyedHandler with a keyFunc returning r.URL.Path.
+func KeyedHandler(keyFunc func(*http.Request) string, lookup map[string]string, fallback http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if dest, ok := lookup[keyFunc(r)]; ok {
+			http.Redirect(w, r, dest, http.StatusFound)
+			return
+		}
+
+		fallback.ServeHTTP(w, r)
+	}
+}
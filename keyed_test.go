@@ -0,0 +1,48 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestKeyedHandler(t *testing.T) {
+	h := KeyedHandler(func(r *http.Request) string {
+		return r.Header.Get("X-Team") + r.URL.Path
+	}, map[string]string{
+		"red/a":  "https://example.com/red",
+		"blue/a": "https://example.com/blue",
+	}, http.NotFoundHandler())
+
+	tests := []struct {
+		team, want string
+	}{
+		{"red", "https://example.com/red"},
+		{"blue", "https://example.com/blue"},
+		{"green", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/a", nil)
+		if tt.team != "" {
+			r.Header.Set("X-Team", tt.team)
+		}
+		rec := httptest.NewRecorder()
+		h(rec, r)
+		if got := rec.Header().Get("Location"); got != tt.want {
+			t.Errorf("team %q: got Location %q, want %q", tt.team, got, tt.want)
+		}
+	}
+}
+
+func TestKeyedHandlerByPath(t *testing.T) {
+	m := map[string]string{"/a": "https://example.com/a"}
+	keyed := KeyedHandler(func(r *http.Request) string { return r.URL.Path }, m, http.NotFoundHandler())
+	plain := MapHandler(m, http.NotFoundHandler())
+	for _, path := range []string{"/a", "/b"} {
+		got, want := get(keyed, path), get(plain, path)
+		if got.Code != want.Code || got.Header().Get("Location") != want.Header().Get("Location") {
+			t.Errorf("%s: got %d %q, want %d %q like MapHandler", path, got.Code, got.Header().Get("Location"), want.Code, want.Header().Get("Location"))
+		}
+	}
+}
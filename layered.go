@@ -0,0 +1,71 @@
+package urlshort
+
+import (
+	"maps"
+	"net/http"
+)
+
+// LayeredHandler is an http.Handler that serves a fixed base
+// mapping, such as one loaded from YAML at startup, under an
+// overlay that can be changed while it is serving. Paths in
+// the overlay shadow the same paths in the base; deleting them
+// from the overlay reveals the base again. It is safe for
+// concurrent use.
+type LayeredHandler struct {
+	// AllowedHosts, if not empty, restricts redirects to
+	// destinations on these hosts, in the base as well as the
+	// overlay; requests for paths mapped elsewhere are passed
+	// to the fallback. It must not be changed while h is in
+	// use.
+	AllowedHosts []string
+
+	base     map[string]string
+	overlay  *DynamicHandler
+	fallback http.Handler
+}
+
+// NewLayeredHandler returns a LayeredHandler with a copy of
+// base and an empty overlay. Paths in neither are passed to
+// the fallback http.Handler.
+func NewLayeredHandler(base map[string]string, fallback http.Handler) *LayeredHandler {
+	base = maps.Clone(base)
+	return &LayeredHandler{
+		base:     base,
+		overlay:  NewDynamicHandler(MapHandler(base, fallback)),
+		fallback: fallback,
+	}
+}
+
+// Set maps path to url in the overlay.
+func (h *LayeredHandler) Set(path, url string) {
+	h.overlay.Set(path, url)
+}
+
+// Delete removes the overlay mapping for path, if any. A base
+// mapping for path is not affected.
+func (h *LayeredHandler) Delete(path string) {
+	h.overlay.Delete(path)
+}
+
+// Links returns a copy of the effective mapping of paths to
+// urls, the base with the overlay applied.
+func (h *LayeredHandler) Links() map[string]string {
+	return LoadLayered(h.base, h.overlay.Links())
+}
+
+// ServeHTTP redirects to the URL mapped to the request path in
+// the overlay, then the base, or calls the fallback if there
+// is none.
+func (h *LayeredHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if len(h.AllowedHosts) > 0 {
+		dest, ok := h.overlay.lookup(r.URL.Path)
+		if !ok {
+			dest, ok = h.base[r.URL.Path]
+		}
+		if ok && !hostAllowed(dest, h.AllowedHosts) {
+			h.fallback.ServeHTTP(w, r)
+			return
+		}
+	}
+	h.overlay.ServeHTTP(w, r)
+}
@@ -0,0 +1,85 @@
+package urlshort
+
+import (
+	"maps"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestLayeredHandler(t *testing.T) {
+	base := map[string]string{"/a": "https://example.com/base"}
+	h := NewLayeredHandler(base, http.NotFoundHandler())
+	base["/a"] = "https://example.com/changed"
+
+	if got := get(h, "/a").Header().Get("Location"); got != "https://example.com/base" {
+		t.Errorf("/a: got Location %q, want the copied base", got)
+	}
+
+	h.Set("/a", "https://example.com/overlay")
+	h.Set("/b", "https://example.com/b")
+	if got := get(h, "/a").Header().Get("Location"); got != "https://example.com/overlay" {
+		t.Errorf("/a: got Location %q, want the overlay", got)
+	}
+	want := map[string]string{"/a": "https://example.com/overlay", "/b": "https://example.com/b"}
+	if got := h.Links(); !maps.Equal(got, want) {
+		t.Errorf("Links: got %v, want %v", got, want)
+	}
+
+	h.Delete("/a")
+	h.Delete("/b")
+	if got := get(h, "/a").Header().Get("Location"); got != "https://example.com/base" {
+		t.Errorf("/a: got Location %q after Delete, want the base again", got)
+	}
+	if rec := get(h, "/b"); rec.Code != http.StatusNotFound {
+		t.Errorf("/b: got code %d after Delete, want fallback", rec.Code)
+	}
+	h.Delete("/a")
+	if rec := get(h, "/a"); rec.Code != http.StatusFound {
+		t.Errorf("/a: got code %d, Delete removed the base mapping", rec.Code)
+	}
+}
+
+func TestLayeredHandlerConcurrent(t *testing.T) {
+	h := NewLayeredHandler(map[string]string{"/a": "https://example.com/a"}, http.NotFoundHandler())
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			for range 100 {
+				h.Set("/b", "https://example.com/b")
+				h.Delete("/b")
+			}
+		})
+		wg.Go(func() {
+			for range 100 {
+				get(h, "/a")
+				h.Links()
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestLayeredHandlerAllowedHosts(t *testing.T) {
+	h := NewLayeredHandler(map[string]string{
+		"/a":        "https://example.com/a",
+		"/base-bad": "https://evil.example/",
+		"/shadowed": "https://evil.example/",
+	}, http.NotFoundHandler())
+	h.AllowedHosts = []string{"example.com"}
+	h.Set("/b", "https://example.com/b")
+	h.Set("/over-bad", "https://evil.example/")
+	h.Set("/shadowed", "https://example.com/fixed")
+
+	for path, want := range map[string]string{
+		"/a":        "https://example.com/a",
+		"/b":        "https://example.com/b",
+		"/shadowed": "https://example.com/fixed",
+		"/base-bad": "",
+		"/over-bad": "",
+	} {
+		if got := get(h, path).Header().Get("Location"); got != want {
+			t.Errorf("%s: got Location %q, want %q", path, got, want)
+		}
+	}
+}
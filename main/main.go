@@ -17,7 +17,21 @@ func main() {
 	}
 	mapHandler := urlshort.MapHandler(pathsToUrls, mux)
 
-	// Build the YAMLHandler using the mapHandler as the
+	// Build the JSONHandler using the mapHandler as the
+	// fallback
+	jsn := `
+	[
+	  {
+		"path": "/parse-json",
+		"url": "https://jsonformatter.org/json-parser"
+	  }
+	]`
+	jsonHandler, err := urlshort.JSONHandler([]byte(jsn), mapHandler)
+	if err != nil {
+		panic(err)
+	}
+
+	// Build the YAMLHandler using the jsonHandler as the
 	// fallback
 	yaml := `
  - path: /urlshort
@@ -25,18 +39,7 @@ func main() {
  - path: /urlshort-final
    url: https://github.com/gophercises/urlshort/tree/solution
  `
-	yamlHandler, err := urlshort.YAMLHandler([]byte(yaml), mapHandler)
-	if err != nil {
-		panic(err)
-	}
-
-	jsn := `
-	{
-		"path": "/parse-json",
-		"url": "https://jsonformatter.org/json-parser"
-	  }`
-
-	jsonHandler, err := urlshort.JSONHandler([]byte(jsn), mapHandler)
+	yamlHandler, err := urlshort.YAMLHandler([]byte(yaml), jsonHandler)
 	if err != nil {
 		panic(err)
 	}
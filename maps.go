@@ -0,0 +1,134 @@
+package urlshort
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Reverse inverts pathsToUrls, grouping all paths that point
+// at the same URL. The paths for each URL are sorted.
+func Reverse(pathsToUrls map[string]string) map[string][]string {
+	urlsToPaths := make(map[string][]string)
+	for path, url := range pathsToUrls {
+		urlsToPaths[url] = append(urlsToPaths[url], path)
+	}
+	for _, paths := range urlsToPaths {
+		sort.Strings(paths)
+	}
+	return urlsToPaths
+}
+
+// Paths returns the paths of pathsToUrls in sorted order, for
+// example to render an index of the available links.
+func Paths(pathsToUrls map[string]string) []string {
+	return sortedKeys(pathsToUrls)
+}
+
+// NormalizeMap returns a copy of pathsToUrls checked and
+// normalized the way the config formats are: paths missing a
+// leading slash get one, and an error is returned for empty
+// destinations and relative ones that redirect a path to
+// itself. A path already written with its slash wins over the
+// same path without one. It is meant for mappings loaded from
+// other sources, such as a database.
+func NormalizeMap(pathsToUrls map[string]string) (map[string]string, error) {
+	normalized := make(map[string]string, len(pathsToUrls))
+	for _, path := range sortedKeys(pathsToUrls) {
+		key := normalizePath(path)
+		if _, ok := normalized[key]; ok && key != path {
+			continue
+		}
+		normalized[key] = pathsToUrls[path]
+	}
+	if err := checkDestinations(normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// pagePaths returns the page of sorted paths starting with
+// prefix described by offset and limit, along with the number
+// of paths starting with prefix. A limit of zero or less means
+// no limit.
+func pagePaths(sorted []string, prefix string, offset, limit int) ([]string, int) {
+	start := sort.SearchStrings(sorted, prefix)
+	end := start + sort.Search(len(sorted)-start, func(i int) bool {
+		return !strings.HasPrefix(sorted[start+i], prefix)
+	})
+	matching := sorted[start:end]
+
+	offset = min(max(offset, 0), len(matching))
+	page := matching[offset:]
+	if limit > 0 && limit < len(page) {
+		page = page[:limit]
+	}
+	return page, len(matching)
+}
+
+// LoadFiles parses each of the YAML files at paths, in the
+// format accepted by YAMLHandler, and merges them into one
+// mapping that can be passed to MapHandler. Gzipped files are
+// decompressed transparently. Errors name the file they came
+// from, and a path defined in more than one file is reported
+// along with both file names.
+func LoadFiles(paths []string) (map[string]string, error) {
+	pathsToUrls := make(map[string]string)
+	sources := make(map[string]string)
+	for _, file := range paths {
+		m, err := loadYAMLFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range sortedKeys(m) {
+			if prev, ok := sources[path]; ok {
+				return nil, fmt.Errorf("urlshort: path %q defined in both %s and %s", path, prev, file)
+			}
+			sources[path] = file
+			pathsToUrls[path] = m[path]
+		}
+	}
+	return pathsToUrls, nil
+}
+
+// LoadLayered merges override onto base and returns the result
+// as a new map; neither argument is modified. Paths in both
+// take the url from override. An override can add or change
+// paths but not remove them.
+func LoadLayered(base, override map[string]string) map[string]string {
+	pathsToUrls := make(map[string]string, len(base)+len(override))
+	for path, url := range base {
+		pathsToUrls[path] = url
+	}
+	for path, url := range override {
+		pathsToUrls[path] = url
+	}
+	return pathsToUrls
+}
+
+// LoadLayeredFiles parses the YAML files at basePath and
+// overridePath, as LoadFiles does, and layers them with
+// LoadLayered.
+func LoadLayeredFiles(basePath, overridePath string) (map[string]string, error) {
+	base, err := loadYAMLFile(basePath)
+	if err != nil {
+		return nil, err
+	}
+	override, err := loadYAMLFile(overridePath)
+	if err != nil {
+		return nil, err
+	}
+	return LoadLayered(base, override), nil
+}
+
+func loadYAMLFile(file string) (map[string]string, error) {
+	yml, err := readConfigFile(file)
+	if err != nil {
+		return nil, err
+	}
+	m, err := ParseYAML(yml)
+	if err != nil {
+		return nil, fmt.Errorf("urlshort: %s: %w", file, err)
+	}
+	return m, nil
+}
@@ -0,0 +1,204 @@
+package urlshort
+
+import (
+	"bytes"
+	"compress/gzip"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeTempFile writes data to a file named name in a new
+// temporary directory and returns its path.
+func writeTempFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReverse(t *testing.T) {
+	got := Reverse(map[string]string{
+		"/b":     "https://example.com/shared",
+		"/a":     "https://example.com/shared",
+		"/c":     "https://example.com/own",
+		"/a/sub": "https://example.com/shared",
+	})
+	want := map[string][]string{
+		"https://example.com/shared": {"/a", "/a/sub", "/b"},
+		"https://example.com/own":    {"/c"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if got := Reverse(nil); len(got) != 0 {
+		t.Errorf("nil map: got %v", got)
+	}
+}
+
+func TestLoadFiles(t *testing.T) {
+	a := writeTempFile(t, "a.yaml", []byte("- path: /a\n  url: https://example.com/a\n"))
+	b := writeTempFile(t, "b.yaml", []byte("- path: /b\n  url: https://example.com/b\n"))
+
+	got, err := LoadFiles([]string{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"/a": "https://example.com/a", "/b": "https://example.com/b"}
+	if !maps.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestLoadFilesConflict(t *testing.T) {
+	a := writeTempFile(t, "a.yaml", []byte("- path: /a\n  url: https://example.com/a\n"))
+	c := writeTempFile(t, "c.yaml", []byte("- path: /a\n  url: https://example.com/c\n"))
+
+	_, err := LoadFiles([]string{a, c})
+	if err == nil {
+		t.Fatal("conflicting files accepted")
+	}
+	for _, want := range []string{a, c, `"/a"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %s: %v", want, err)
+		}
+	}
+}
+
+func TestLoadFilesErrorNamesFile(t *testing.T) {
+	bad := writeTempFile(t, "bad.yaml", []byte("- path: ["))
+	if _, err := LoadFiles([]string{bad}); err == nil || !strings.Contains(err.Error(), bad) {
+		t.Errorf("got error %v, want one naming %s", err, bad)
+	}
+	if _, err := LoadFiles([]string{filepath.Join(t.TempDir(), "missing.yaml")}); err == nil {
+		t.Error("missing file accepted")
+	}
+}
+
+// gzipped returns data compressed with gzip.
+func gzipped(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestLoadFilesGzip(t *testing.T) {
+	a := writeTempFile(t, "a.yaml.gz", gzipped(t, "- path: /a\n  url: https://example.com/a\n"))
+	// Without the suffix, the file is recognized by its magic bytes.
+	b := writeTempFile(t, "b.yaml", gzipped(t, "- path: /b\n  url: https://example.com/b\n"))
+	c := writeTempFile(t, "c.yaml", []byte("- path: /c\n  url: https://example.com/c\n"))
+
+	got, err := LoadFiles([]string{a, b, c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"/a": "https://example.com/a",
+		"/b": "https://example.com/b",
+		"/c": "https://example.com/c",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestLoadFilesCorruptGzip(t *testing.T) {
+	bad := writeTempFile(t, "bad.yaml.gz", []byte("- path: /a\n  url: https://example.com/a\n"))
+	if _, err := LoadFiles([]string{bad}); err == nil || !strings.Contains(err.Error(), bad) {
+		t.Errorf("got error %v, want one naming %s", err, bad)
+	}
+}
+
+func TestPaths(t *testing.T) {
+	got := Paths(map[string]string{
+		"/c": "https://example.com/c",
+		"/a": "https://example.com/a",
+		"/b": "https://example.com/b",
+	})
+	if want := []string{"/a", "/b", "/c"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := Paths(nil); len(got) != 0 {
+		t.Errorf("nil map: got %v, want no paths", got)
+	}
+}
+
+func TestLoadLayered(t *testing.T) {
+	base := map[string]string{"/a": "https://example.com/a", "/b": "https://example.com/b"}
+	override := map[string]string{"/b": "https://example.com/override", "/c": "https://example.com/c"}
+	got := LoadLayered(base, override)
+	want := map[string]string{
+		"/a": "https://example.com/a",
+		"/b": "https://example.com/override",
+		"/c": "https://example.com/c",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if base["/b"] != "https://example.com/b" || len(base) != 2 {
+		t.Errorf("base was modified: %v", base)
+	}
+	if len(override) != 2 {
+		t.Errorf("override was modified: %v", override)
+	}
+}
+
+func TestLoadLayeredFiles(t *testing.T) {
+	base := writeTempFile(t, "base.yaml", []byte("- path: /a\n  url: https://example.com/a\n- path: /b\n  url: https://example.com/b\n"))
+	override := writeTempFile(t, "override.yaml", []byte("- path: /b\n  url: https://example.com/override\n"))
+
+	got, err := LoadLayeredFiles(base, override)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"/a": "https://example.com/a", "/b": "https://example.com/override"}
+	if !maps.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	bad := writeTempFile(t, "bad.yaml", []byte("- path: ["))
+	if _, err := LoadLayeredFiles(base, bad); err == nil || !strings.Contains(err.Error(), bad) {
+		t.Errorf("got error %v, want one naming %s", err, bad)
+	}
+	if _, err := LoadLayeredFiles(base, base+".missing"); err == nil {
+		t.Errorf("missing override: no error")
+	}
+}
+
+func TestPagePaths(t *testing.T) {
+	sorted := []string{"/a", "/blog/1", "/blog/2", "/blog/3", "/c"}
+	tests := []struct {
+		prefix        string
+		offset, limit int
+		want          []string
+		total         int
+	}{
+		{"/blog/", 0, 2, []string{"/blog/1", "/blog/2"}, 3},
+		{"/blog/", 2, 2, []string{"/blog/3"}, 3},
+		{"/blog/", 5, 2, []string{}, 3},
+		{"", -1, 0, sorted, 5},
+		{"", 1, -1, sorted[1:], 5},
+		{"/zz", 0, 3, []string{}, 0},
+		{"/", 4, 10, []string{"/c"}, 5},
+	}
+	for _, tt := range tests {
+		got, total := pagePaths(sorted, tt.prefix, tt.offset, tt.limit)
+		if !slices.Equal(got, tt.want) || total != tt.total {
+			t.Errorf("%q offset %d limit %d: got %v of %d, want %v of %d", tt.prefix, tt.offset, tt.limit, got, total, tt.want, tt.total)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package urlshort
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// lookupFunc resolves a request path to its destination.
+type lookupFunc func(path string) (dest string, ok bool)
+
+// newLookup returns a lookupFunc applying the matching rules
+// enabled in opts. Each rule is only consulted when the rules
+// before it found no match, so exact matches always win.
+func newLookup(pathsToUrls map[string]string, opts Options) lookupFunc {
+	lookup := exactLookup(pathsToUrls)
+	if opts.NormalizeUnicode {
+		lookup = unicodeLookup(pathsToUrls, lookup)
+	}
+	if opts.CaseInsensitive {
+		lookup = foldedLookup(pathsToUrls, lookup)
+	}
+	if opts.IgnoreTrailingSlash {
+		lookup = slashLookup(pathsToUrls, opts.CaseInsensitive, lookup)
+	}
+	if opts.Wildcard {
+		lookup = wildcardLookup(pathsToUrls, opts.CaseInsensitive, lookup)
+	}
+	return lookup
+}
+
+func exactLookup(pathsToUrls map[string]string) lookupFunc {
+	return func(path string) (string, bool) {
+		dest, ok := pathsToUrls[path]
+		return dest, ok
+	}
+}
+
+// foldedLookup matches paths regardless of case. Among keys
+// that differ only in case, the one that sorts first wins.
+func foldedLookup(pathsToUrls map[string]string, next lookupFunc) lookupFunc {
+	folded := make(map[string]string, len(pathsToUrls))
+	for _, path := range sortedKeys(pathsToUrls) {
+		lower := strings.ToLower(path)
+		if _, ok := folded[lower]; !ok {
+			folded[lower] = pathsToUrls[path]
+		}
+	}
+
+	return func(path string) (string, bool) {
+		if dest, ok := next(path); ok {
+			return dest, true
+		}
+		dest, ok := folded[strings.ToLower(path)]
+		return dest, ok
+	}
+}
+
+// unicodeLookup matches paths after percent-decoding the map
+// keys and bringing keys and request paths to Unicode NFC, so
+// that "/caf%C3%A9", "/café" and its decomposed form all match.
+// Keys with invalid percent-encoding are only NFC-normalized.
+func unicodeLookup(pathsToUrls map[string]string, next lookupFunc) lookupFunc {
+	normalized := make(map[string]string, len(pathsToUrls))
+	for _, path := range sortedKeys(pathsToUrls) {
+		key := path
+		if unescaped, err := url.PathUnescape(path); err == nil {
+			key = unescaped
+		}
+		key = norm.NFC.String(key)
+		if _, ok := normalized[key]; !ok {
+			normalized[key] = pathsToUrls[path]
+		}
+	}
+
+	return func(path string) (string, bool) {
+		if dest, ok := next(path); ok {
+			return dest, true
+		}
+		dest, ok := normalized[norm.NFC.String(path)]
+		return dest, ok
+	}
+}
+
+// slashLookup treats paths with and without a trailing slash
+// as the same path. The root path is left alone.
+func slashLookup(pathsToUrls map[string]string, foldCase bool, next lookupFunc) lookupFunc {
+	normalize := func(path string) string {
+		if len(path) > 1 {
+			path = strings.TrimRight(path, "/")
+			if path == "" {
+				path = "/"
+			}
+		}
+		if foldCase {
+			path = strings.ToLower(path)
+		}
+		return path
+	}
+
+	trimmed := make(map[string]string, len(pathsToUrls))
+	for _, path := range sortedKeys(pathsToUrls) {
+		key := normalize(path)
+		if _, ok := trimmed[key]; !ok {
+			trimmed[key] = pathsToUrls[path]
+		}
+	}
+
+	return func(path string) (string, bool) {
+		if dest, ok := next(path); ok {
+			return dest, true
+		}
+		dest, ok := trimmed[normalize(path)]
+		return dest, ok
+	}
+}
+
+type wildcard struct {
+	prefix string
+	dest   string
+}
+
+// wildcardLookup matches keys ending in "/*" against any path
+// with that prefix, appending the rest of the path to the
+// destination with appendPath. The longest matching prefix
+// wins.
+func wildcardLookup(pathsToUrls map[string]string, foldCase bool, next lookupFunc) lookupFunc {
+	var wildcards []wildcard
+	for _, path := range sortedKeys(pathsToUrls) {
+		if !strings.HasSuffix(path, "/*") {
+			continue
+		}
+		prefix := strings.TrimSuffix(path, "*")
+		if foldCase {
+			prefix = strings.ToLower(prefix)
+		}
+		wildcards = append(wildcards, wildcard{prefix: prefix, dest: pathsToUrls[path]})
+	}
+	sort.SliceStable(wildcards, func(i, j int) bool {
+		return len(wildcards[i].prefix) > len(wildcards[j].prefix)
+	})
+
+	return func(path string) (string, bool) {
+		if dest, ok := next(path); ok {
+			return dest, true
+		}
+		match := path
+		if foldCase {
+			match = strings.ToLower(path)
+		}
+		for _, wc := range wildcards {
+			if strings.HasPrefix(match, wc.prefix) {
+				return appendPath(wc.dest, path[len(wc.prefix):])
+			}
+		}
+		return "", false
+	}
+}
+
+// appendPath appends the unescaped path rest to the path of
+// dest, separated by a single slash, and reports false if dest
+// cannot be parsed. rest is escaped and loses its leading
+// slashes, so it can neither change the host of dest, even for
+// a relative dest such as "/", nor add a query or fragment;
+// those of dest are kept.
+func appendPath(dest, rest string) (string, bool) {
+	if rest == "" {
+		return dest, true
+	}
+	u, err := url.Parse(dest)
+	if err != nil {
+		return "", false
+	}
+
+	escaped := u.EscapedPath()
+	if !strings.HasSuffix(escaped, "/") {
+		escaped += "/"
+	}
+	escaped += escapePathPart(rest)
+	if u.Path, err = url.PathUnescape(escaped); err != nil {
+		return "", false
+	}
+	u.RawPath = escaped
+	return u.String(), true
+}
+
+// escapePathPart escapes the unescaped path s, stripped of its
+// leading slashes, for use within the path of a URL. The result
+// cannot start a scheme-relative URL or add a query or fragment.
+func escapePathPart(s string) string {
+	return (&url.URL{Path: strings.TrimLeft(s, "/")}).EscapedPath()
+}
+
+func sortedKeys(pathsToUrls map[string]string) []string {
+	keys := make([]string, 0, len(pathsToUrls))
+	for path := range pathsToUrls {
+		keys = append(keys, path)
+	}
+	sort.Strings(keys)
+	return keys
+}
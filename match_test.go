@@ -0,0 +1,115 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestWildcardHandler(t *testing.T) {
+	h := WildcardHandler(map[string]string{
+		"/docs/*":    "https://docs.example.com",
+		"/docs/v2/*": "https://v2.example.com/",
+		"/docs/faq":  "https://example.com/faq",
+		"/q/*":       "https://example.com/search?ref=short",
+		"/rel/*":     "/new",
+	}, http.NotFoundHandler())
+
+	tests := []struct {
+		target, want string
+	}{
+		{"/docs/a/b", "https://docs.example.com/a/b"},
+		{"/docs/", "https://docs.example.com"},
+		{"/docs/faq", "https://example.com/faq"},
+		{"/docs/v2/x", "https://v2.example.com/x"},
+		{"/q/go", "https://example.com/search/go?ref=short"},
+		{"/rel/x", "/new/x"},
+		{"/other", ""},
+	}
+	for _, tt := range tests {
+		if got := get(h, tt.target).Header().Get("Location"); got != tt.want {
+			t.Errorf("%s: got Location %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestWildcardRemainderEscaped(t *testing.T) {
+	h := WildcardHandler(map[string]string{
+		"/docs/*": "https://docs.example.com",
+		"/rel/*":  "/new",
+	}, http.NotFoundHandler())
+
+	tests := []struct {
+		target, want string
+	}{
+		{"/docs/.evil.com/x", "https://docs.example.com/.evil.com/x"},
+		{"/docs/a%3Fb%23c", "https://docs.example.com/a%3Fb%23c"},
+		{"/docs/a%25b", "https://docs.example.com/a%25b"},
+		{"/docs/café", "https://docs.example.com/caf%C3%A9"},
+		{"/docs//evil.com", "https://docs.example.com/evil.com"},
+		{"/rel///evil.com", "/new/evil.com"},
+	}
+	for _, tt := range tests {
+		if got := get(h, tt.target).Header().Get("Location"); got != tt.want {
+			t.Errorf("%s: got Location %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreTrailingSlash(t *testing.T) {
+	h := MapHandlerWithOptions(map[string]string{
+		"/a":  "https://example.com/a",
+		"/b/": "https://example.com/b",
+		"/":   "https://example.com/root",
+	}, Options{IgnoreTrailingSlash: true}, http.NotFoundHandler())
+
+	tests := []struct {
+		target, want string
+	}{
+		{"/a", "https://example.com/a"},
+		{"/a/", "https://example.com/a"},
+		{"/b", "https://example.com/b"},
+		{"/b/", "https://example.com/b"},
+		{"/", "https://example.com/root"},
+		{"/c/", ""},
+	}
+	for _, tt := range tests {
+		if got := get(h, tt.target).Header().Get("Location"); got != tt.want {
+			t.Errorf("%s: got Location %q, want %q", tt.target, got, tt.want)
+		}
+	}
+
+	strict := MapHandler(map[string]string{"/a": "https://example.com/a"}, http.NotFoundHandler())
+	if rec := get(strict, "/a/"); rec.Code != http.StatusNotFound {
+		t.Errorf("MapHandler matched /a/ to /a")
+	}
+}
+
+func TestNormalizeUnicode(t *testing.T) {
+	m := map[string]string{
+		"/café":       "https://example.com/cafe",
+		"/na%C3%AFve": "https://example.com/naive",
+		"/bad%zz":     "https://example.com/bad",
+	}
+	h := MapHandlerWithOptions(m, Options{NormalizeUnicode: true}, http.NotFoundHandler())
+	tests := []struct {
+		target, want string
+	}{
+		{"/caf%C3%A9", "https://example.com/cafe"},
+		// "e" followed by a combining acute accent.
+		{"/cafe%CC%81", "https://example.com/cafe"},
+		{"/na%C3%AFve", "https://example.com/naive"},
+		{"/nai%CC%88ve", "https://example.com/naive"},
+		{"/bad%25zz", "https://example.com/bad"},
+		{"/cafe", ""},
+	}
+	for _, tt := range tests {
+		if got := get(h, tt.target).Header().Get("Location"); got != tt.want {
+			t.Errorf("%s: got Location %q, want %q", tt.target, got, tt.want)
+		}
+	}
+
+	plain := MapHandler(m, http.NotFoundHandler())
+	if rec := get(plain, "/cafe%CC%81"); rec.Code != http.StatusNotFound {
+		t.Errorf("decomposed form matched without NormalizeUnicode")
+	}
+}
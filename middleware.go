@@ -0,0 +1,192 @@
+package urlshort
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// StripPrefix returns an http.HandlerFunc that removes prefix
+// from the start of the request path before calling h, so
+// handlers built for "/github" also serve "/r/github" when
+// mounted under "/r". The prefix only matches whole path
+// segments, so "/r" strips "/r/github" and "/r" but leaves
+// "/rgithub" alone. The stripped path always starts with a
+// slash. Requests whose path does not start with prefix are
+// passed to h unchanged.
+func StripPrefix(prefix string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		path, ok := strings.CutPrefix(r.URL.Path, prefix)
+		if ok && path != "" && !strings.HasPrefix(path, "/") && !strings.HasSuffix(prefix, "/") {
+			ok = false
+		}
+		if !ok || prefix == "" {
+			h(w, r)
+			return
+		}
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = path
+		r2.URL.RawPath = ""
+		h(w, r2)
+	}
+}
+
+// LoggingHandler returns an http.Handler that calls h and then
+// logs the request path, the status and, for redirects, the
+// destination at info level. A request counts as a hit when h
+// answered with a redirect, and as a miss otherwise. If logger
+// is nil, h is returned unchanged.
+func LoggingHandler(logger *slog.Logger, h http.Handler) http.Handler {
+	if logger == nil {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w}
+		h.ServeHTTP(rec, r)
+		logRequest(logger, r, rec)
+	})
+}
+
+// LogSampling configures SampledLoggingHandler. Hits and
+// Misses give the rate at which each kind of request is
+// logged: a rate of N logs one in N requests on average, 1
+// logs all of them, and zero or less logs none.
+type LogSampling struct {
+	Hits   int
+	Misses int
+
+	// Rand returns a random number in [0, 1) used to decide
+	// whether to log a request. It must be safe for concurrent
+	// use. Nil means rand.Float64.
+	Rand func() float64
+}
+
+// SampledLoggingHandler works like LoggingHandler, but only
+// logs a random sample of requests, as configured by sampling,
+// to keep busy paths from flooding the log. If logger is nil,
+// h is returned unchanged.
+func SampledLoggingHandler(logger *slog.Logger, sampling LogSampling, h http.Handler) http.Handler {
+	if logger == nil {
+		return h
+	}
+
+	rnd := sampling.Rand
+	if rnd == nil {
+		rnd = rand.Float64
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w}
+		h.ServeHTTP(rec, r)
+
+		rate := sampling.Misses
+		if rec.isRedirect() {
+			rate = sampling.Hits
+		}
+		if rate > 0 && rnd()*float64(rate) < 1 {
+			logRequest(logger, r, rec)
+		}
+	})
+}
+
+func logRequest(logger *slog.Logger, r *http.Request, rec *statusRecorder) {
+	logger.LogAttrs(r.Context(), slog.LevelInfo, "urlshort request",
+		slog.String("path", r.URL.Path),
+		slog.Bool("hit", rec.isRedirect()),
+		slog.String("destination", rec.location()),
+		slog.Int("status", rec.code()),
+	)
+}
+
+// statusRecorder wraps an http.ResponseWriter to remember the
+// status code written through it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+func (rec *statusRecorder) code() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+	return rec.status
+}
+
+func (rec *statusRecorder) isRedirect() bool {
+	return isRedirectStatus(rec.code()) && rec.location() != ""
+}
+
+func (rec *statusRecorder) location() string {
+	return rec.Header().Get("Location")
+}
+
+// TimedHandler returns an http.Handler that calls h and passes
+// how long it took to record, for example to feed a latency
+// histogram. If record is nil, h is returned unchanged.
+func TimedHandler(record func(time.Duration), h http.Handler) http.Handler {
+	if record == nil {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		h.ServeHTTP(w, r)
+		record(time.Since(start))
+	})
+}
+
+// ObserveHandler returns an http.Handler that calls h and then
+// passes the request, along with the status code and Location
+// header of the response, to observe, for example to export
+// metrics or annotate a trace. A redirect is a 3xx status with
+// a non-empty location. If observe is nil, h is returned
+// unchanged.
+func ObserveHandler(observe func(r *http.Request, status int, location string), h http.Handler) http.Handler {
+	if observe == nil {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w}
+		h.ServeHTTP(rec, r)
+		observe(r, rec.code(), rec.location())
+	})
+}
+
+// Middleware returns a middleware form of MapHandler: the
+// handler it wraps, typically the next one in a middleware
+// chain, serves as the fallback for paths not in pathsToUrls.
+func Middleware(pathsToUrls map[string]string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return MapHandler(pathsToUrls, next)
+	}
+}
@@ -0,0 +1,167 @@
+package urlshort
+
+import (
+	"bytes"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStripPrefix(t *testing.T) {
+	m := MapHandler(map[string]string{
+		"/":        "https://example.com/root",
+		"/github":  "https://github.com",
+		"/rgithub": "https://example.com/rgithub",
+	}, http.NotFoundHandler())
+
+	tests := []struct {
+		prefix, target, want string
+	}{
+		{"/r", "/r/github", "https://github.com"},
+		{"/r", "/r", "https://example.com/root"},
+		{"/r", "/r/", "https://example.com/root"},
+		{"/r", "/rgithub", "https://example.com/rgithub"},
+		{"/r", "/github", "https://github.com"},
+		{"/r/", "/r/github", "https://github.com"},
+		{"", "/github", "https://github.com"},
+	}
+	for _, tt := range tests {
+		h := StripPrefix(tt.prefix, m)
+		if got := get(h, tt.target).Header().Get("Location"); got != tt.want {
+			t.Errorf("StripPrefix(%q) %s: got Location %q, want %q", tt.prefix, tt.target, got, tt.want)
+		}
+	}
+
+	if rec := get(StripPrefix("/r", m), "/r/missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("/r/missing: got code %d, want fallback", rec.Code)
+	}
+}
+
+func TestStripPrefixKeepsRequest(t *testing.T) {
+	var seen string
+	h := StripPrefix("/r", func(w http.ResponseWriter, r *http.Request) { seen = r.URL.Path })
+	r, _ := http.NewRequest(http.MethodGet, "/r/a%2Fb", nil)
+	h(nil, r)
+	if seen != "/a/b" {
+		t.Errorf("got path %q, want /a/b", seen)
+	}
+	if r.URL.Path != "/r/a/b" {
+		t.Errorf("original request modified to %q", r.URL.Path)
+	}
+}
+
+func TestLoggingHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	h := LoggingHandler(logger, MapHandler(map[string]string{"/a": "https://example.com/a"}, http.NotFoundHandler()))
+
+	get(h, "/a")
+	get(h, "/missing")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		`level=INFO msg="urlshort request" path=/a hit=true destination=https://example.com/a status=302`,
+		`level=INFO msg="urlshort request" path=/missing hit=false destination="" status=404`,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), buf.String())
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d:\ngot  %s\nwant %s", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestLoggingHandlerNilLogger(t *testing.T) {
+	m := MapHandler(map[string]string{"/a": "https://example.com/a"}, http.NotFoundHandler())
+	if rec := get(LoggingHandler(nil, m), "/a"); rec.Code != http.StatusFound {
+		t.Errorf("got code %d, want 302", rec.Code)
+	}
+}
+
+func TestTimedHandler(t *testing.T) {
+	var got []time.Duration
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusTeapot)
+	})
+	h := TimedHandler(func(d time.Duration) { got = append(got, d) }, slow)
+	if rec := get(h, "/"); rec.Code != http.StatusTeapot {
+		t.Errorf("got code %d, want the response of the wrapped handler", rec.Code)
+	}
+	if len(got) != 1 || got[0] < 10*time.Millisecond {
+		t.Errorf("recorded %v, want one duration of at least 10ms", got)
+	}
+
+	if rec := get(TimedHandler(nil, slow), "/"); rec.Code != http.StatusTeapot {
+		t.Errorf("nil record: got code %d, want the response of the wrapped handler", rec.Code)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	mw := Middleware(map[string]string{"/a": "https://example.com/a"})
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	h := mw(next)
+	if got := get(h, "/a").Header().Get("Location"); got != "https://example.com/a" {
+		t.Errorf("/a: got Location %q, want https://example.com/a", got)
+	}
+	if rec := get(h, "/b"); rec.Code != http.StatusTeapot {
+		t.Errorf("/b: got code %d, want the next handler's", rec.Code)
+	}
+}
+
+func TestSampledLoggingHandler(t *testing.T) {
+	m := MapHandler(map[string]string{"/a": "https://example.com/a"}, http.NotFoundHandler())
+	tests := []struct {
+		name     string
+		sampling LogSampling
+		path     string
+		x        float64
+		logged   bool
+	}{
+		{"hit sampled", LogSampling{Hits: 10}, "/a", 0.05, true},
+		{"hit skipped", LogSampling{Hits: 10}, "/a", 0.15, false},
+		{"hit rate 1", LogSampling{Hits: 1}, "/a", 0.99, true},
+		{"hit rate 0", LogSampling{Misses: 1}, "/a", 0, false},
+		{"miss sampled", LogSampling{Hits: 1, Misses: 4}, "/b", 0.2, true},
+		{"miss skipped", LogSampling{Hits: 1, Misses: 4}, "/b", 0.3, false},
+		{"miss rate negative", LogSampling{Hits: 1, Misses: -1}, "/b", 0, false},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		sampling := tt.sampling
+		x := tt.x
+		sampling.Rand = func() float64 { return x }
+		h := SampledLoggingHandler(slog.New(slog.NewTextHandler(&buf, nil)), sampling, m)
+		get(h, tt.path)
+		if logged := buf.Len() > 0; logged != tt.logged {
+			t.Errorf("%s: logged %v, want %v", tt.name, logged, tt.logged)
+		}
+	}
+}
+
+func TestSampledLoggingHandlerRate(t *testing.T) {
+	var buf bytes.Buffer
+	rng := rand.New(rand.NewPCG(1, 2))
+	h := SampledLoggingHandler(slog.New(slog.NewTextHandler(&buf, nil)), LogSampling{Hits: 10, Rand: rng.Float64},
+		MapHandler(map[string]string{"/a": "https://example.com/a"}, http.NotFoundHandler()))
+	for range 1000 {
+		get(h, "/a")
+	}
+	if n := strings.Count(buf.String(), "\n"); n < 70 || n > 130 {
+		t.Errorf("logged %d of 1000 hits, want about 100", n)
+	}
+}
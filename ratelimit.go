@@ -0,0 +1,112 @@
+package urlshort
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimitOptions configures RateLimitedWithOptions.
+type RateLimitOptions struct {
+	// TrustForwardedFor takes the client IP from the first
+	// address in the X-Forwarded-For header when present.
+	// Only enable it behind a proxy that sets the header, as
+	// clients can otherwise pick their own IP.
+	TrustForwardedFor bool
+
+	// IdleTimeout is how long the limiter of a client is kept
+	// after its last request. It defaults to ten minutes.
+	IdleTimeout time.Duration
+}
+
+// RateLimited returns an http.Handler that allows each client
+// IP, taken from the request's RemoteAddr, rps requests per
+// second with bursts of up to burst requests before calling h.
+// Requests over the limit get http.StatusTooManyRequests.
+func RateLimited(h http.Handler, rps float64, burst int) http.Handler {
+	return RateLimitedWithOptions(h, rps, burst, RateLimitOptions{})
+}
+
+// RateLimitedWithOptions works like RateLimited, configured by
+// opts.
+func RateLimitedWithOptions(h http.Handler, rps float64, burst int, opts RateLimitOptions) http.Handler {
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = 10 * time.Minute
+	}
+	l := &ipLimiter{
+		limit:     rate.Limit(rps),
+		burst:     burst,
+		idle:      opts.IdleTimeout,
+		clients:   make(map[string]*client),
+		lastSwept: time.Now(),
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.allow(clientIP(r, opts.TrustForwardedFor)) {
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+type client struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+type ipLimiter struct {
+	limit rate.Limit
+	burst int
+	idle  time.Duration
+
+	mu        sync.Mutex
+	clients   map[string]*client
+	lastSwept time.Time
+}
+
+func (l *ipLimiter) allow(ip string) bool {
+	t := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Forget idle clients at most once per idle period so the
+	// map cannot grow without bound.
+	if t.Sub(l.lastSwept) >= l.idle {
+		for ip, c := range l.clients {
+			if t.Sub(c.lastSeen) >= l.idle {
+				delete(l.clients, ip)
+			}
+		}
+		l.lastSwept = t
+	}
+
+	c, ok := l.clients[ip]
+	if !ok {
+		c = &client{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[ip] = c
+	}
+	c.lastSeen = t
+	return c.limiter.AllowN(t, 1)
+}
+
+// clientIP returns the IP of the client that sent r.
+func clientIP(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			first, _, _ := strings.Cut(fwd, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,57 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// getFrom serves a GET request for target from remoteAddr with
+// h, setting X-Forwarded-For to xff if it is not empty.
+func getFrom(h http.Handler, target, remoteAddr, xff string) int {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	r.RemoteAddr = remoteAddr
+	if xff != "" {
+		r.Header.Set("X-Forwarded-For", xff)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec.Code
+}
+
+func TestRateLimited(t *testing.T) {
+	m := MapHandler(map[string]string{"/a": "https://example.com/a"}, http.NotFoundHandler())
+	// A rate this low never refills during the test.
+	h := RateLimited(m, 0.001, 2)
+
+	want := []int{http.StatusFound, http.StatusFound, http.StatusTooManyRequests}
+	for i, code := range want {
+		if got := getFrom(h, "/a", "192.0.2.1:1234", ""); got != code {
+			t.Errorf("request %d: got code %d, want %d", i, got, code)
+		}
+	}
+	if got := getFrom(h, "/a", "192.0.2.1:5678", ""); got != http.StatusTooManyRequests {
+		t.Errorf("same IP, other port: got code %d, want 429", got)
+	}
+	if got := getFrom(h, "/a", "192.0.2.2:1234", ""); got != http.StatusFound {
+		t.Errorf("other IP: got code %d, want 302", got)
+	}
+	if got := getFrom(h, "/a", "192.0.2.1:1234", "198.51.100.7"); got != http.StatusTooManyRequests {
+		t.Errorf("untrusted X-Forwarded-For: got code %d, want 429", got)
+	}
+}
+
+func TestRateLimitedTrustForwardedFor(t *testing.T) {
+	m := MapHandler(map[string]string{"/a": "https://example.com/a"}, http.NotFoundHandler())
+	h := RateLimitedWithOptions(m, 0.001, 1, RateLimitOptions{TrustForwardedFor: true})
+
+	if got := getFrom(h, "/a", "10.0.0.1:1234", "198.51.100.7, 10.0.0.1"); got != http.StatusFound {
+		t.Errorf("first client: got code %d, want 302", got)
+	}
+	if got := getFrom(h, "/a", "10.0.0.1:1234", "198.51.100.8, 10.0.0.1"); got != http.StatusFound {
+		t.Errorf("second client behind the same proxy: got code %d, want 302", got)
+	}
+	if got := getFrom(h, "/a", "10.0.0.1:1234", "198.51.100.7"); got != http.StatusTooManyRequests {
+		t.Errorf("first client again: got code %d, want 429", got)
+	}
+}
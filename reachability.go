@@ -0,0 +1,74 @@
+package urlshort
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// reachabilityWorkers bounds the number of concurrent requests
+// made by CheckReachability.
+const reachabilityWorkers = 8
+
+// CheckReachability sends a HEAD request with client to the
+// destination of every path in pathsToUrls and returns the
+// result for each path: nil if the destination responded with
+// a status below 400, or the error otherwise. Relative
+// destinations cannot be checked and are reported as errors.
+//
+// At most eight requests are in flight at a time. Timeouts are
+// up to client, or http.DefaultClient if it is nil; canceling
+// ctx aborts the outstanding requests.
+func CheckReachability(ctx context.Context, pathsToUrls map[string]string, client *http.Client) map[string]error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	results := make(map[string]error, len(pathsToUrls))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	paths := make(chan string)
+	for range min(reachabilityWorkers, len(pathsToUrls)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				err := checkReachable(ctx, client, pathsToUrls[path])
+				mu.Lock()
+				results[path] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, path := range sortedKeys(pathsToUrls) {
+		paths <- path
+	}
+	close(paths)
+	wg.Wait()
+	return results
+}
+
+func checkReachable(ctx context.Context, client *http.Client, dest string) error {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return err
+	}
+	if !u.IsAbs() {
+		return fmt.Errorf("urlshort: %q is relative and cannot be checked", dest)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, dest, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("urlshort: %s responded with %s", dest, resp.Status)
+	}
+	return nil
+}
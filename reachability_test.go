@@ -0,0 +1,72 @@
+package urlshort
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheckReachability(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		if r.Method != http.MethodHead {
+			t.Errorf("got method %s, want HEAD", r.Method)
+		}
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/moved":
+			w.Header().Set("Location", "/ok")
+			w.WriteHeader(http.StatusMovedPermanently)
+		}
+	}))
+	defer srv.Close()
+
+	m := map[string]string{
+		"/missing":  srv.URL + "/missing",
+		"/moved":    srv.URL + "/moved",
+		"/relative": "/elsewhere",
+	}
+	for i := range 20 {
+		m[fmt.Sprintf("/ok%d", i)] = srv.URL + "/ok"
+	}
+	results := CheckReachability(context.Background(), m, srv.Client())
+	if len(results) != len(m) {
+		t.Fatalf("got %d results, want one per path", len(results))
+	}
+	for path, err := range results {
+		wantErr := path == "/missing" || path == "/relative"
+		if (err != nil) != wantErr {
+			t.Errorf("%s: got error %v, want error %v", path, err, wantErr)
+		}
+	}
+	if n := maxInFlight.Load(); n > reachabilityWorkers {
+		t.Errorf("got %d requests in flight, want at most %d", n, reachabilityWorkers)
+	}
+}
+
+func TestCheckReachabilityCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results := CheckReachability(ctx, map[string]string{"/a": "https://example.com/a"}, nil)
+	if results["/a"] == nil {
+		t.Errorf("got no error for a canceled context")
+	}
+	if results := CheckReachability(ctx, nil, nil); len(results) != 0 {
+		t.Errorf("empty map: got %v", results)
+	}
+}
@@ -0,0 +1,86 @@
+package urlshort
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// YAMLHandlerReader works like YAMLHandler, but reads the YAML
+// from r until EOF. Errors from r are returned as is.
+func YAMLHandlerReader(r io.Reader, fallback http.Handler) (http.HandlerFunc, error) {
+	yml, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return YAMLHandler(yml, fallback)
+}
+
+// JSONHandlerReader works like JSONHandler, but reads the JSON
+// from r until EOF. Errors from r are returned as is.
+func JSONHandlerReader(r io.Reader, fallback http.Handler) (http.HandlerFunc, error) {
+	jsn, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return JSONHandler(jsn, fallback)
+}
+
+// TOMLHandlerReader works like TOMLHandler, but reads the TOML
+// from r until EOF. Errors from r are returned as is.
+func TOMLHandlerReader(r io.Reader, fallback http.Handler) (http.HandlerFunc, error) {
+	tml, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return TOMLHandler(tml, fallback)
+}
+
+// JSONLHandler works like JSONHandler, but reads newline
+// delimited JSON from r until EOF, with one entry per line:
+//
+//	{"path": "/some-path", "url": "https://www.some-url.com/demo"}
+//	{"path": "/campaign", "url": "https://www.some-url.com/sale", "expires": "2024-01-31T23:59:59Z"}
+//
+// Each line takes the same fields as an entry of JSONHandler.
+// Blank lines are skipped. Lines are decoded one at a time,
+// so the input is never held in memory as a whole. A line that
+// cannot be parsed is reported with its line number; errors
+// from r are returned as is.
+func JSONLHandler(r io.Reader, fallback http.Handler) (http.HandlerFunc, error) {
+	pathUrls, err := parseJsonl(r)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := buildEntriesJson(pathUrls)
+	if err != nil {
+		return nil, err
+	}
+	return entryHandler(entries, ConfigOptions{}, fallback), nil
+}
+
+func parseJsonl(r io.Reader) ([]pathUrlJson, error) {
+	var pathUrls []pathUrlJson
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			var p pathUrlJson
+			if jerr := json.Unmarshal(trimmed, &p); jerr != nil {
+				return nil, fmt.Errorf("urlshort: line %d: %w", n, jerr)
+			}
+			pathUrls = append(pathUrls, p)
+		}
+		if err != nil {
+			return pathUrls, nil
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package urlshort
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestHandlerReaders(t *testing.T) {
+	tests := []struct {
+		name string
+		h    func(io.Reader, http.Handler) (http.HandlerFunc, error)
+		data string
+	}{
+		{"YAML", YAMLHandlerReader, "- path: /a\n  url: https://example.com/a\n"},
+		{"JSON", JSONHandlerReader, `[{"path": "/a", "url": "https://example.com/a"}]`},
+		{"TOML", TOMLHandlerReader, "[[paths]]\npath = \"/a\"\nurl = \"https://example.com/a\"\n"},
+	}
+	for _, tt := range tests {
+		h, err := tt.h(iotest.OneByteReader(strings.NewReader(tt.data)), http.NotFoundHandler())
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := get(h, "/a").Header().Get("Location"); got != "https://example.com/a" {
+			t.Errorf("%s: got Location %q", tt.name, got)
+		}
+	}
+}
+
+func TestHandlerReadersError(t *testing.T) {
+	errRead := errors.New("read failed")
+	for name, h := range map[string]func(io.Reader, http.Handler) (http.HandlerFunc, error){
+		"YAML": YAMLHandlerReader,
+		"JSON": JSONHandlerReader,
+		"TOML": TOMLHandlerReader,
+	} {
+		if _, err := h(iotest.ErrReader(errRead), http.NotFoundHandler()); err != errRead {
+			t.Errorf("%s: got error %v, want the reader's", name, err)
+		}
+	}
+}
+
+func TestJSONLHandler(t *testing.T) {
+	jsonl := `{"path": "/a", "url": "https://example.com/a"}
+
+   
+{"path": "b", "url": "https://example.com/b"}
+{"path": "/old", "url": "https://example.com/old", "expires": "2000-01-01T00:00:00Z"}`
+	h, err := JSONLHandler(iotest.OneByteReader(strings.NewReader(jsonl)), http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		target string
+		code   int
+		want   string
+	}{
+		{"/a", http.StatusFound, "https://example.com/a"},
+		{"/b", http.StatusFound, "https://example.com/b"},
+		{"/old", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rec := get(h, tt.target)
+		if rec.Code != tt.code || rec.Header().Get("Location") != tt.want {
+			t.Errorf("%s: got %d %q, want %d %q", tt.target, rec.Code, rec.Header().Get("Location"), tt.code, tt.want)
+		}
+	}
+}
+
+func TestJSONLHandlerErrors(t *testing.T) {
+	_, err := JSONLHandler(strings.NewReader("{\"path\": \"/a\", \"url\": \"https://example.com/a\"}\n\n{bad\n"), http.NotFoundHandler())
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("got error %v, want one naming line 3", err)
+	}
+
+	errRead := errors.New("read failed")
+	if _, err := JSONLHandler(iotest.ErrReader(errRead), http.NotFoundHandler()); err != errRead {
+		t.Errorf("got error %v, want the reader's", err)
+	}
+}
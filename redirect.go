@@ -0,0 +1,65 @@
+package urlshort
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+)
+
+// preparedRedirect is the response http.Redirect writes for a
+// fixed absolute destination and status. Handlers compute it
+// once when they are built, so that serving a hit allocates
+// only its header values.
+type preparedRedirect struct {
+	location string
+	body     []byte
+}
+
+type redirectKey struct {
+	url    string
+	status int
+}
+
+// prepareRedirect records the response http.Redirect gives a
+// GET request for dest. It reports false for destinations
+// http.Redirect resolves against the request path, which
+// cannot be prepared.
+func prepareRedirect(dest string, status int) (preparedRedirect, bool) {
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme == "" {
+		return preparedRedirect{}, false
+	}
+	rec := &redirectRecorder{header: make(http.Header)}
+	http.Redirect(rec, &http.Request{Method: http.MethodGet}, dest, status)
+	return preparedRedirect{location: rec.header.Get("Location"), body: rec.body.Bytes()}, true
+}
+
+// serve writes p in response to r, exactly as http.Redirect
+// would. Each response gets header values of its own, so that
+// a wrapping handler modifying them in place cannot affect
+// other responses; both are cut from a single allocation, with
+// their capacity capped so that appending to one copies it.
+func (p preparedRedirect) serve(w http.ResponseWriter, r *http.Request, status int) {
+	h := w.Header()
+	_, hadCT := h["Content-Type"]
+	values := []string{p.location, "text/html; charset=utf-8"}
+	h["Location"] = values[0:1:1]
+	if !hadCT && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+		h["Content-Type"] = values[1:2:2]
+	}
+	w.WriteHeader(status)
+	if !hadCT && r.Method == http.MethodGet {
+		w.Write(p.body)
+	}
+}
+
+// redirectRecorder is a minimal http.ResponseWriter capturing
+// the header and body written by prepareRedirect.
+type redirectRecorder struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (rec *redirectRecorder) Header() http.Header         { return rec.header }
+func (rec *redirectRecorder) Write(b []byte) (int, error) { return rec.body.Write(b) }
+func (rec *redirectRecorder) WriteHeader(int)             {}
@@ -0,0 +1,162 @@
+package urlshort
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPreparedRedirectMatchesRedirect(t *testing.T) {
+	dests := []string{
+		"https://example.com/a?q=1&b=<2>",
+		"https://ü.example/ä",
+		"mailto:someone@example.com",
+		`http://example.com/"quoted"`,
+	}
+	for _, dest := range dests {
+		for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodPost} {
+			for _, presetCT := range []bool{false, true} {
+				h := MapHandlerWithStatus(map[string]string{"/a": dest}, http.StatusMovedPermanently, nil)
+				r := httptest.NewRequest(method, "/a", nil)
+				got, want := httptest.NewRecorder(), httptest.NewRecorder()
+				if presetCT {
+					got.Header().Set("Content-Type", "text/plain")
+					want.Header().Set("Content-Type", "text/plain")
+				}
+				h(got, r)
+				http.Redirect(want, r, dest, http.StatusMovedPermanently)
+
+				if got.Code != want.Code {
+					t.Errorf("%s %s: status %d, want %d", method, dest, got.Code, want.Code)
+				}
+				if got.Body.String() != want.Body.String() {
+					t.Errorf("%s %s: body %q, want %q", method, dest, got.Body, want.Body)
+				}
+				if len(got.Header()) != len(want.Header()) {
+					t.Errorf("%s %s: header %v, want %v", method, dest, got.Header(), want.Header())
+				}
+				for k := range want.Header() {
+					if got.Header().Get(k) != want.Header().Get(k) {
+						t.Errorf("%s %s: %s %q, want %q", method, dest, k, got.Header().Get(k), want.Header().Get(k))
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestPreparedRedirectHeadersNotShared(t *testing.T) {
+	h := MapHandler(map[string]string{"/a": "https://example.com/a"}, nil)
+	tamper := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+		w.Header()["Location"][0] = "https://evil.example/"
+		w.Header()["Content-Type"][0] = "text/plain"
+	})
+	tamper.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/a", nil))
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/a", nil))
+	if got := rec.Header().Get("Location"); got != "https://example.com/a" {
+		t.Errorf("Location = %q after tampering with an earlier response", got)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q after tampering with an earlier response", got)
+	}
+}
+
+func TestPrepareRedirectRelative(t *testing.T) {
+	for _, dest := range []string{"/b", "b", "../b", "//example.com/b"} {
+		if _, ok := prepareRedirect(dest, http.StatusFound); ok {
+			t.Errorf("%s: prepared, want it left to http.Redirect", dest)
+		}
+	}
+	if _, ok := prepareRedirect("https://example.com/b", http.StatusFound); !ok {
+		t.Errorf("absolute destination not prepared")
+	}
+}
+
+func TestMapHandlerHitAllocs(t *testing.T) {
+	h := MapHandler(map[string]string{"/a": "https://example.com/a"}, nil)
+	r := httptest.NewRequest(http.MethodGet, "/a", nil)
+	w := &discardWriter{header: make(http.Header)}
+	// The one allocation left is the header values each
+	// response gets of its own.
+	if n := testing.AllocsPerRun(100, func() { h(w, r) }); n > 1 {
+		t.Errorf("got %v allocations per hit, want at most 1", n)
+	}
+}
+
+// discardWriter is an http.ResponseWriter that keeps nothing
+// but its header, so that benchmarks measure the handler alone.
+type discardWriter struct{ header http.Header }
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+
+func benchmarkHit(b *testing.B, h http.Handler) {
+	r := httptest.NewRequest(http.MethodGet, "/a", nil)
+	w := &discardWriter{header: make(http.Header)}
+	b.ReportAllocs()
+	for b.Loop() {
+		h.ServeHTTP(w, r)
+	}
+}
+
+func BenchmarkMapHandlerHit(b *testing.B) {
+	benchmarkHit(b, MapHandler(map[string]string{"/a": "https://example.com/a"}, nil))
+}
+
+func BenchmarkYAMLHandlerHit(b *testing.B) {
+	h, err := YAMLHandler([]byte("- path: /a\n  url: https://example.com/a\n"), nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarkHit(b, h)
+}
+
+func BenchmarkRedirect(b *testing.B) {
+	benchmarkHit(b, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://example.com/a", http.StatusFound)
+	}))
+}
+
+func BenchmarkParseYAML(b *testing.B) {
+	yml := []byte(benchmarkYAML(100))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := YAMLHandler(yml, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseJSON(b *testing.B) {
+	var buf strings.Builder
+	buf.WriteString("[")
+	for i := range 100 {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		fmt.Fprintf(&buf, `{"path": "/p%d", "url": "https://example.com/%d"}`, i, i)
+	}
+	buf.WriteString("]")
+	jsn := []byte(buf.String())
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := JSONHandler(jsn, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkYAML returns a YAML config of n entries.
+func benchmarkYAML(n int) string {
+	var buf strings.Builder
+	for i := range n {
+		fmt.Fprintf(&buf, "- path: /p%d\n  url: https://example.com/%d\n", i, i)
+	}
+	return buf.String()
+}
@@ -0,0 +1,88 @@
+package urlshort
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// RegexRule maps request paths matching Pattern to the URL
+// produced by expanding Template, in which $1, ${name} and so
+// on refer to the capture groups of Pattern as in
+// regexp.Regexp.Expand.
+type RegexRule struct {
+	Pattern  string
+	Template string
+}
+
+type compiledRule struct {
+	re       *regexp.Regexp
+	template string
+}
+
+// RegexHandler will return an http.HandlerFunc (which also
+// implements http.Handler) that tries rules in order against
+// the request path and redirects to the expanded template of
+// the first rule that matches. If no rule matches, then the
+// fallback http.Handler will be called instead.
+//
+// Patterns are not anchored implicitly, so "/u/(\d+)" also
+// matches "/x/u/42/y"; use ^ and $ to match the whole path.
+// The destination is always the expanded template alone.
+// Captures are path-escaped before they are expanded, so that
+// a request cannot add a query or fragment to the destination,
+// and a rule whose destination would come out as a
+// scheme-relative URL such as "//evil.com" is skipped, so that
+// "^/old/(.*)$" to "/$1" cannot send "/old//evil.com" to
+// another host.
+//
+// An error is returned if any pattern fails to compile.
+func RegexHandler(rules []RegexRule, fallback http.Handler) (http.HandlerFunc, error) {
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("urlshort: invalid pattern %q: %w", rule.Pattern, err)
+		}
+		compiled = append(compiled, compiledRule{re: re, template: rule.Template})
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		for _, rule := range compiled {
+			match := rule.re.FindStringSubmatchIndex(path)
+			if match == nil {
+				continue
+			}
+			src, match := escapeCaptures(path, match)
+			dest := string(rule.re.ExpandString(nil, rule.template, src, match))
+			if strings.HasPrefix(dest, "//") {
+				continue
+			}
+			http.Redirect(w, r, dest, http.StatusFound)
+			return
+		}
+
+		fallback.ServeHTTP(w, r)
+	}, nil
+}
+
+// escapeCaptures returns a source text holding the path-escaped
+// submatches of path described by match, and the indexes of
+// those submatches within it, for regexp.Regexp.ExpandString.
+func escapeCaptures(path string, match []int) (string, []int) {
+	var src strings.Builder
+	indexes := make([]int, len(match))
+	for i := 0; i < len(match); i += 2 {
+		if match[i] < 0 {
+			indexes[i], indexes[i+1] = -1, -1
+			continue
+		}
+		indexes[i] = src.Len()
+		src.WriteString((&url.URL{Path: path[match[i]:match[i+1]]}).EscapedPath())
+		indexes[i+1] = src.Len()
+	}
+	return src.String(), indexes
+}
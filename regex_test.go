@@ -0,0 +1,65 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRegexHandler(t *testing.T) {
+	h, err := RegexHandler([]RegexRule{
+		{`^/u/(\d+)$`, "https://example.com/users/$1"},
+		{`^/u/(?P<name>\w+)$`, "https://example.com/by-name/${name}"},
+		{`/p/(\w+)`, "https://example.com/p/${1}"},
+	}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		target, want string
+	}{
+		{"/u/42", "https://example.com/users/42"},
+		{"/u/gopher", "https://example.com/by-name/gopher"},
+		{"/u/42/x", ""},
+		{"/a/p/zz/q", "https://example.com/p/zz"},
+		{"/other", ""},
+	}
+	for _, tt := range tests {
+		if got := get(h, tt.target).Header().Get("Location"); got != tt.want {
+			t.Errorf("%s: got Location %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestRegexHandlerInvalidPattern(t *testing.T) {
+	if _, err := RegexHandler([]RegexRule{{"(", "https://example.com/"}}, http.NotFoundHandler()); err == nil {
+		t.Error("invalid pattern accepted")
+	}
+}
+
+func TestRegexHandlerEscapesCaptures(t *testing.T) {
+	h, err := RegexHandler([]RegexRule{
+		{`^/old/(.*)$`, "/$1"},
+		{`^/docs(/.*)$`, "https://example.com/docs$1"},
+	}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		target, want string
+	}{
+		{"/old/a/b", "/a/b"},
+		{"/old/a%3Fadmin=1", "/a%3Fadmin=1"},
+		{"/old/a%23x", "/a%23x"},
+		{"/old/a%20b", "/a%20b"},
+		{"/old//evil.com/x", ""},
+		{"/docs/a%3Fx", "https://example.com/docs/a%3Fx"},
+		{"/docs//x", "https://example.com/docs//x"},
+	}
+	for _, tt := range tests {
+		if got := get(h, tt.target).Header().Get("Location"); got != tt.want {
+			t.Errorf("%s: got Location %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package urlshort
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// ReloadableHandler is an http.Handler whose whole config can
+// be replaced while it is serving, for callers that watch or
+// fetch their config themselves. It is safe for concurrent
+// use.
+type ReloadableHandler struct {
+	fallback http.Handler
+	current  atomic.Pointer[http.HandlerFunc]
+}
+
+// NewReloadableHandler returns a ReloadableHandler that passes
+// every request to the fallback http.Handler until Reload is
+// first called successfully.
+func NewReloadableHandler(fallback http.Handler) *ReloadableHandler {
+	h := &ReloadableHandler{fallback: fallback}
+	serveFallback := http.HandlerFunc(fallback.ServeHTTP)
+	h.current.Store(&serveFallback)
+	return h
+}
+
+// Reload parses data in the named format, which is one of
+// those accepted by RemoteHandler, and atomically switches to
+// serving it. Requests already being served finish with the
+// previous config. If data cannot be parsed, the error is
+// returned and the previous config keeps being served.
+func (h *ReloadableHandler) Reload(format string, data []byte) error {
+	handler, err := formatHandler(format, data, h.fallback)
+	if err != nil {
+		return err
+	}
+	h.current.Store(&handler)
+	return nil
+}
+
+// ReloadFile works like Reload, but reads the config from the
+// file at path. The file may be gzipped, in which case it is
+// decompressed transparently.
+func (h *ReloadableHandler) ReloadFile(format, path string) error {
+	data, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+	return h.Reload(format, data)
+}
+
+// ServeHTTP serves the request with the current config.
+func (h *ReloadableHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	(*h.current.Load()).ServeHTTP(w, r)
+}
+
+// readConfigFile returns the contents of the file at path,
+// decompressing it first if it is gzipped. Gzipped files are
+// recognized by a .gz suffix or by the gzip magic bytes.
+func readConfigFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") && !bytes.HasPrefix(data, gzipMagic) {
+		return data, nil
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("urlshort: decompressing %s: %w", path, err)
+	}
+	defer zr.Close()
+	data, err = io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("urlshort: decompressing %s: %w", path, err)
+	}
+	return data, nil
+}
+
+var gzipMagic = []byte{0x1f, 0x8b}
@@ -0,0 +1,79 @@
+package urlshort
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestReloadableHandler(t *testing.T) {
+	h := NewReloadableHandler(http.NotFoundHandler())
+	if rec := get(h, "/a"); rec.Code != http.StatusNotFound {
+		t.Errorf("before Reload: got code %d, want fallback", rec.Code)
+	}
+
+	if err := h.Reload("yaml", []byte("- path: /a\n  url: https://example.com/a\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(h, "/a").Header().Get("Location"); got != "https://example.com/a" {
+		t.Errorf("after Reload: got Location %q, want https://example.com/a", got)
+	}
+
+	if err := h.Reload("json", []byte(`[{"path": "/b", "url": "https://example.com/b"}]`)); err != nil {
+		t.Fatal(err)
+	}
+	if rec := get(h, "/a"); rec.Code != http.StatusNotFound {
+		t.Errorf("/a: got code %d after reloading without it, want fallback", rec.Code)
+	}
+}
+
+func TestReloadableHandlerKeepsConfigOnError(t *testing.T) {
+	h := NewReloadableHandler(http.NotFoundHandler())
+	if err := h.Reload("yaml", []byte("- path: /a\n  url: https://example.com/a\n")); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct{ format, data string }{
+		{"json", "[{"},
+		{"yaml", "- path: /a\n  url: \"\"\n"},
+		{"bogus", ""},
+	} {
+		if err := h.Reload(tt.format, []byte(tt.data)); err == nil {
+			t.Errorf("%s %q: no error", tt.format, tt.data)
+		}
+	}
+	if got := get(h, "/a").Header().Get("Location"); got != "https://example.com/a" {
+		t.Errorf("got Location %q after failed reloads, want the last good config", got)
+	}
+}
+
+func TestReloadableHandlerReloadFile(t *testing.T) {
+	h := NewReloadableHandler(http.NotFoundHandler())
+	path := writeTempFile(t, "redirects.yaml.gz", gzipped(t, "- path: /a\n  url: https://example.com/a\n"))
+	if err := h.ReloadFile("yaml", path); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(h, "/a").Header().Get("Location"); got != "https://example.com/a" {
+		t.Errorf("got Location %q, want https://example.com/a", got)
+	}
+	if err := h.ReloadFile("yaml", path+".missing"); err == nil {
+		t.Errorf("missing file: no error")
+	}
+}
+
+func TestReloadableHandlerConcurrent(t *testing.T) {
+	h := NewReloadableHandler(http.NotFoundHandler())
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			for range 50 {
+				h.Reload("yaml", []byte("- path: /a\n  url: https://example.com/a\n"))
+			}
+		})
+		wg.Go(func() {
+			for range 50 {
+				get(h, "/a")
+			}
+		})
+	}
+	wg.Wait()
+}
@@ -0,0 +1,60 @@
+package urlshort
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// remoteTimeout bounds the fetch done by RemoteHandler.
+const remoteTimeout = 30 * time.Second
+
+// RemoteHandler will fetch the config at configURL and then
+// return an http.HandlerFunc (which also implements
+// http.Handler) that will attempt to map any paths to their
+// corresponding URL. If the path is not provided in the
+// config, then the fallback http.Handler will be called
+// instead.
+//
+// format names the format of the config, such as "yaml" or
+// "json", and selects the matching handler, like YAMLHandler
+// or JSONHandler. The config is fetched once, giving up after
+// 30 seconds; see RemoteHandlerContext to control the fetch.
+//
+// An error is returned if the fetch fails, the server does not
+// respond with http.StatusOK, or the config cannot be parsed.
+func RemoteHandler(configURL string, format string, fallback http.Handler) (http.HandlerFunc, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	defer cancel()
+	return RemoteHandlerContext(ctx, configURL, format, fallback)
+}
+
+// RemoteHandlerContext works like RemoteHandler, but fetches
+// the config with ctx, which also bounds how long the fetch
+// may take.
+func RemoteHandlerContext(ctx context.Context, configURL string, format string, fallback http.Handler) (http.HandlerFunc, error) {
+	data, err := fetchConfig(ctx, configURL)
+	if err != nil {
+		return nil, err
+	}
+	return formatHandler(format, data, fallback)
+}
+
+func fetchConfig(ctx context.Context, configURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, configURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("urlshort: fetching %s: unexpected status %s", configURL, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
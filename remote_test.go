@@ -0,0 +1,77 @@
+package urlshort
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newConfigServer returns a test server serving a YAML config
+// at /redirects.yaml, a JSON one at /redirects.json, one that
+// never responds at /slow and errors everywhere else.
+func newConfigServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirects.yaml":
+			w.Write([]byte("- path: /a\n  url: https://example.com/a\n"))
+		case "/redirects.json":
+			w.Write([]byte(`[{"path": "/a", "url": "https://example.com/a"}]`))
+		case "/invalid.yaml":
+			w.Write([]byte("- path: ["))
+		case "/slow":
+			<-r.Context().Done()
+		default:
+			http.Error(w, "no such config", http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRemoteHandler(t *testing.T) {
+	srv := newConfigServer(t)
+	for _, tt := range []struct{ path, format string }{
+		{"/redirects.yaml", "yaml"},
+		{"/redirects.json", "json"},
+	} {
+		h, err := RemoteHandler(srv.URL+tt.path, tt.format, http.NotFoundHandler())
+		if err != nil {
+			t.Errorf("%s: %v", tt.path, err)
+			continue
+		}
+		if got := get(h, "/a").Header().Get("Location"); got != "https://example.com/a" {
+			t.Errorf("%s: got Location %q, want https://example.com/a", tt.path, got)
+		}
+	}
+}
+
+func TestRemoteHandlerErrors(t *testing.T) {
+	srv := newConfigServer(t)
+	tests := []struct {
+		name   string
+		url    string
+		format string
+	}{
+		{"bad status", srv.URL + "/missing", "yaml"},
+		{"invalid config", srv.URL + "/invalid.yaml", "yaml"},
+		{"unknown format", srv.URL + "/redirects.yaml", "ini5"},
+		{"bad url", "://nope", "yaml"},
+	}
+	for _, tt := range tests {
+		if _, err := RemoteHandler(tt.url, tt.format, http.NotFoundHandler()); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+}
+
+func TestRemoteHandlerContextTimeout(t *testing.T) {
+	srv := newConfigServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := RemoteHandlerContext(ctx, srv.URL+"/slow", "yaml", http.NotFoundHandler()); err == nil {
+		t.Errorf("fetch outlived its context")
+	}
+}
@@ -0,0 +1,64 @@
+package urlshort
+
+import "net/http"
+
+// Resolve reports where MapHandler would redirect a request
+// for path, without serving one. It is meant for checking a
+// mapping before shipping it.
+func Resolve(pathsToUrls map[string]string, path string) (dest string, matched bool) {
+	return ResolveWithOptions(pathsToUrls, Options{}, path)
+}
+
+// ResolveWithOptions works like Resolve, but applies the same
+// matching and rewriting rules as MapHandlerWithOptions does
+// with opts. BeforeRedirect and PreserveQuery depend on the
+// request and are not applied.
+func ResolveWithOptions(pathsToUrls map[string]string, opts Options, path string) (dest string, matched bool) {
+	return newResolver(pathsToUrls, opts).resolve(nil, path)
+}
+
+// resolver holds the lookup shared by MapHandlerWithOptions
+// and ResolveWithOptions, so that both follow the same rules.
+type resolver struct {
+	pathsToUrls map[string]string
+	lookup      lookupFunc
+	opts        Options
+}
+
+func newResolver(pathsToUrls map[string]string, opts Options) resolver {
+	if opts.DefaultScheme != "" {
+		pathsToUrls = withDefaultScheme(pathsToUrls, opts.DefaultScheme)
+	}
+	return resolver{
+		pathsToUrls: pathsToUrls,
+		lookup:      newLookup(pathsToUrls, opts),
+		opts:        opts,
+	}
+}
+
+// resolve returns the destination for path. The rules that
+// need the request are skipped when r is nil.
+func (rs resolver) resolve(r *http.Request, path string) (string, bool) {
+	opts := rs.opts
+	dest, ok := rs.lookup(path)
+	if ok && r != nil && opts.BeforeRedirect != nil {
+		dest, ok = opts.BeforeRedirect(r, dest)
+	}
+	if ok && len(opts.AllowedHosts) > 0 {
+		ok = hostAllowed(dest, opts.AllowedHosts)
+	}
+	if !ok {
+		return "", false
+	}
+
+	if opts.UpgradeHTTPS {
+		dest = upgradeHTTPS(dest, opts.UpgradeHosts)
+	}
+	if r != nil && opts.PreserveQuery {
+		dest = mergeQuery(dest, r.URL.RawQuery)
+	}
+	if len(opts.QueryParams) > 0 {
+		dest = addQueryParams(dest, opts.QueryParams)
+	}
+	return dest, true
+}
@@ -0,0 +1,45 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	m := map[string]string{
+		"/a":      "https://example.com/a",
+		"/docs/*": "https://example.com/docs/",
+		"/B/":     "http://example.com/b",
+	}
+	tests := []struct {
+		name string
+		opts Options
+		path string
+		want string
+		ok   bool
+	}{
+		{"exact", Options{}, "/a", "https://example.com/a", true},
+		{"miss", Options{}, "/zz", "", false},
+		{"case sensitive", Options{}, "/b/", "", false},
+		{"no wildcards", Options{}, "/docs/x", "", false},
+		{"normalized", Options{CaseInsensitive: true, IgnoreTrailingSlash: true}, "/b", "http://example.com/b", true},
+		{"upgraded", Options{CaseInsensitive: true, UpgradeHTTPS: true}, "/b/", "https://example.com/b", true},
+		{"wildcard", Options{Wildcard: true}, "/docs/x/y", "https://example.com/docs/x/y", true},
+		{"disallowed host", Options{AllowedHosts: []string{"other.example"}}, "/a", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ResolveWithOptions(m, tt.opts, tt.path)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: got %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+
+		// Resolve must agree with the handler it mirrors.
+		rec := get(MapHandlerWithOptions(m, tt.opts, http.NotFoundHandler()), tt.path)
+		if loc := rec.Header().Get("Location"); loc != tt.want {
+			t.Errorf("%s: handler redirected to %q, Resolve gave %q", tt.name, loc, tt.want)
+		}
+	}
+	if got, ok := Resolve(m, "/a"); !ok || got != "https://example.com/a" {
+		t.Errorf("Resolve: got %q, %v", got, ok)
+	}
+}
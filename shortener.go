@@ -0,0 +1,207 @@
+package urlshort
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Store records the codes generated by a Shortener. It must be
+// safe for concurrent use.
+type Store interface {
+	// Get returns the URL stored under code, and false if
+	// there is none.
+	Get(code string) (url string, ok bool, err error)
+
+	// Add stores url under code unless code is already taken,
+	// and reports whether it did.
+	Add(code, url string) (added bool, err error)
+}
+
+// MemoryStore is a Store that keeps codes in memory.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	codes map[string]string
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{codes: make(map[string]string)}
+}
+
+// Get returns the URL stored under code.
+func (s *MemoryStore) Get(code string) (string, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	url, ok := s.codes[code]
+	return url, ok, nil
+}
+
+// Add stores url under code unless code is already taken.
+func (s *MemoryStore) Add(code, url string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.codes[code]; ok {
+		return false, nil
+	}
+	s.codes[code] = url
+	return true, nil
+}
+
+// Links returns a copy of the stored codes as a mapping of
+// paths ("/" followed by the code) to urls, ready for
+// MapHandler or SwapHandler.
+func (s *MemoryStore) Links() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	links := make(map[string]string, len(s.codes))
+	for code, url := range s.codes {
+		links["/"+code] = url
+	}
+	return links
+}
+
+// ShortenerOptions configures NewShortenerWithOptions.
+type ShortenerOptions struct {
+	// Length is the number of characters in a code, between 1
+	// and 10. Zero means 7.
+	Length int
+
+	// Deterministic derives codes from a hash of the URL
+	// rather than at random, so that shortening the same URL
+	// again returns the same code.
+	Deterministic bool
+
+	// Rand is the source of random codes. It must be safe for
+	// concurrent use. Nil means crypto/rand.Reader.
+	Rand io.Reader
+}
+
+// Shortener generates base62 short codes for URLs and records
+// them in a Store. It is safe for concurrent use.
+type Shortener struct {
+	store Store
+	opts  ShortenerOptions
+}
+
+// NewShortener returns a Shortener generating random codes of
+// 7 characters into store.
+func NewShortener(store Store) *Shortener {
+	return NewShortenerWithOptions(store, ShortenerOptions{})
+}
+
+// NewShortenerWithOptions works like NewShortener, configured
+// by opts. It panics if opts.Length is out of range.
+func NewShortenerWithOptions(store Store, opts ShortenerOptions) *Shortener {
+	if opts.Length == 0 {
+		opts.Length = 7
+	}
+	if opts.Rand == nil {
+		opts.Rand = rand.Reader
+	}
+	if opts.Length < 1 || opts.Length > maxCodeLength {
+		panic("urlshort: code length out of range: " + strconv.Itoa(opts.Length))
+	}
+	return &Shortener{store: store, opts: opts}
+}
+
+// maxCodeLength is the longest code whose characters can all
+// be drawn from a single uint64.
+const maxCodeLength = 10
+
+// maxShortenAttempts bounds how many codes Shorten tries
+// before giving up on finding a free one.
+const maxShortenAttempts = 10
+
+// Shorten returns a code for rawURL and records it in the
+// store, so that requests for "/" followed by the code can be
+// redirected to it. If the generated code is already taken by
+// another URL, a new one is generated. With
+// ShortenerOptions.Deterministic set, a URL that was shortened
+// before gets its existing code back.
+//
+// An error is returned if rawURL is empty or cannot be parsed,
+// if the store fails, or if no free code is found.
+func (s *Shortener) Shorten(rawURL string) (code string, err error) {
+	if rawURL == "" {
+		return "", errors.New("urlshort: empty url")
+	}
+	if _, err := url.Parse(rawURL); err != nil {
+		return "", fmt.Errorf("urlshort: invalid url %q: %w", rawURL, err)
+	}
+
+	for attempt := 0; attempt < maxShortenAttempts; attempt++ {
+		code, err := s.generate(rawURL, attempt)
+		if err != nil {
+			return "", err
+		}
+		if s.opts.Deterministic {
+			existing, ok, err := s.store.Get(code)
+			if err != nil {
+				return "", err
+			}
+			if ok && existing == rawURL {
+				return code, nil
+			}
+			if ok {
+				continue
+			}
+		}
+		added, err := s.store.Add(code, rawURL)
+		if err != nil {
+			return "", err
+		}
+		if added {
+			return code, nil
+		}
+	}
+	return "", fmt.Errorf("urlshort: no free code for %q after %d attempts", rawURL, maxShortenAttempts)
+}
+
+const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+func (s *Shortener) generate(rawURL string, attempt int) (string, error) {
+	var b [8]byte
+	if s.opts.Deterministic {
+		sum := sha256.Sum256([]byte(rawURL + "\x00" + strconv.Itoa(attempt)))
+		copy(b[:], sum[:])
+	} else if _, err := io.ReadFull(s.opts.Rand, b[:]); err != nil {
+		return "", fmt.Errorf("urlshort: generating code: %w", err)
+	}
+
+	n := binary.BigEndian.Uint64(b[:])
+	var code strings.Builder
+	for range s.opts.Length {
+		code.WriteByte(base62[n%62])
+		n /= 62
+	}
+	return code.String(), nil
+}
+
+// Handler returns an http.HandlerFunc that redirects requests
+// for "/" followed by a stored code to its URL, looking the
+// code up in the store on every request. Other requests, and
+// requests failing to be looked up, are passed to the
+// fallback. For a store that can list its codes, such as
+// MemoryStore, MapHandler with its Links serves a snapshot.
+func (s *Shortener) Handler(fallback http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code, ok := strings.CutPrefix(r.URL.Path, "/")
+		if ok && code != "" {
+			if dest, ok, err := s.store.Get(code); err == nil && ok {
+				http.Redirect(w, r, dest, http.StatusFound)
+				return
+			}
+		}
+
+		fallback.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,129 @@
+package urlshort
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// zeroReader is a source of randomness that only yields zero
+// bytes, so that every random code comes out the same.
+type zeroReader struct{}
+
+func (zeroReader) Read(b []byte) (int, error) {
+	clear(b)
+	return len(b), nil
+}
+
+func isBase62(code string) bool {
+	for _, c := range code {
+		if !strings.ContainsRune(base62, c) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestShortener(t *testing.T) {
+	store := NewMemoryStore()
+	s := NewShortener(store)
+	a, err := s.Shorten("https://example.com/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a) != 7 || !isBase62(a) {
+		t.Errorf("got code %q, want 7 base62 characters", a)
+	}
+	b, err := s.Shorten("https://example.com/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Errorf("two URLs got the same code %q", a)
+	}
+
+	h := s.Handler(http.NotFoundHandler())
+	if got := get(h, "/"+a).Header().Get("Location"); got != "https://example.com/a" {
+		t.Errorf("Handler: got Location %q, want https://example.com/a", got)
+	}
+	if rec := get(h, "/nope"); rec.Code != http.StatusNotFound {
+		t.Errorf("Handler: unknown code got %d, want fallback", rec.Code)
+	}
+	m := MapHandler(store.Links(), http.NotFoundHandler())
+	if got := get(m, "/"+b).Header().Get("Location"); got != "https://example.com/b" {
+		t.Errorf("MapHandler: got Location %q, want https://example.com/b", got)
+	}
+
+	for _, bad := range []string{"", "://nope"} {
+		if _, err := s.Shorten(bad); err == nil {
+			t.Errorf("Shorten(%q): no error", bad)
+		}
+	}
+}
+
+func TestShortenerDeterministic(t *testing.T) {
+	opts := ShortenerOptions{Deterministic: true, Length: 5}
+	store := NewMemoryStore()
+	s := NewShortenerWithOptions(store, opts)
+	first, err := s.Shorten("https://example.com/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := s.Shorten("https://example.com/a"); again != first {
+		t.Errorf("got %q, then %q for the same URL", first, again)
+	}
+	if len(first) != 5 {
+		t.Errorf("got code %q, want 5 characters", first)
+	}
+	if other, _ := NewShortenerWithOptions(NewMemoryStore(), opts).Shorten("https://example.com/a"); other != first {
+		t.Errorf("another store got %q, want %q", other, first)
+	}
+}
+
+func TestShortenerCollision(t *testing.T) {
+	opts := ShortenerOptions{Deterministic: true}
+	taken, err := NewShortenerWithOptions(NewMemoryStore(), opts).Shorten("https://example.com/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewMemoryStore()
+	store.Add(taken, "https://example.com/other")
+
+	s := NewShortenerWithOptions(store, opts)
+	code, err := s.Shorten("https://example.com/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code == taken {
+		t.Fatalf("got the taken code %q", code)
+	}
+	if again, _ := s.Shorten("https://example.com/b"); again != code {
+		t.Errorf("got %q after the collision, want %q again", again, code)
+	}
+	if url, _, _ := store.Get(taken); url != "https://example.com/other" {
+		t.Errorf("taken code now maps to %q", url)
+	}
+
+	// With a source that repeats itself, the second URL can
+	// never find a free code.
+	r := NewShortenerWithOptions(NewMemoryStore(), ShortenerOptions{Rand: zeroReader{}})
+	if _, err := r.Shorten("https://example.com/x"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Shorten("https://example.com/y"); err == nil || !strings.Contains(err.Error(), "no free code") {
+		t.Errorf("got error %v, want one about no free code", err)
+	}
+}
+
+func TestShortenerLengthOutOfRange(t *testing.T) {
+	for _, n := range []int{-1, 11} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Length %d: no panic", n)
+				}
+			}()
+			NewShortenerWithOptions(NewMemoryStore(), ShortenerOptions{Length: n})
+		}()
+	}
+}
@@ -0,0 +1,74 @@
+package urlshort
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// SignedHandler works like MapHandler, but only redirects
+// requests carrying a valid signature for their path, as
+// produced by SignPath with the same secret. Requests with a
+// missing, invalid or expired signature are passed to the
+// fallback http.Handler.
+//
+// An error is returned if secret is empty, as anyone could
+// forge signatures made with it.
+func SignedHandler(pathsToUrls map[string]string, secret []byte, fallback http.Handler) (http.HandlerFunc, error) {
+	if len(secret) == 0 {
+		return nil, errEmptySecret
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if dest, ok := pathsToUrls[path]; ok && validSignature(secret, path, r.URL.Query()) {
+			http.Redirect(w, r, dest, http.StatusFound)
+			return
+		}
+
+		fallback.ServeHTTP(w, r)
+	}, nil
+}
+
+// SignPath returns path with exp and sig query parameters
+// that make SignedHandler accept it until expires. An error is
+// returned if secret is empty.
+func SignPath(secret []byte, path string, expires time.Time) (string, error) {
+	if len(secret) == 0 {
+		return "", errEmptySecret
+	}
+
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	q := url.Values{
+		"exp": {exp},
+		"sig": {signature(secret, path, exp)},
+	}
+	return path + "?" + q.Encode(), nil
+}
+
+var errEmptySecret = errors.New("urlshort: empty secret")
+
+func validSignature(secret []byte, path string, q url.Values) bool {
+	exp, sig := q.Get("exp"), q.Get("sig")
+	if exp == "" || sig == "" {
+		return false
+	}
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() >= expires {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(signature(secret, path, exp)))
+}
+
+func signature(secret []byte, path, exp string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(path))
+	mac.Write([]byte{0})
+	mac.Write([]byte(exp))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,64 @@
+package urlshort
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignedHandler(t *testing.T) {
+	secret := []byte("test secret")
+	h, err := SignedHandler(map[string]string{
+		"/a": "https://example.com/a",
+		"/b": "https://example.com/b",
+	}, secret, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	future := time.Now().Add(time.Hour)
+	valid, err := SignPath(secret, "/a", future)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := SignPath(secret, "/a", time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := SignPath([]byte("other secret"), "/a", future)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		target string
+		code   int
+	}{
+		{valid, http.StatusFound},
+		{"/a", http.StatusNotFound},
+		{strings.Replace(valid, "/a", "/b", 1), http.StatusNotFound},
+		{strings.Replace(valid, "sig=", "sig=x", 1), http.StatusNotFound},
+		{expired, http.StatusNotFound},
+		{otherKey, http.StatusNotFound},
+		{"/a?exp=notanumber&sig=x", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := get(h, tt.target)
+		if rec.Code != tt.code {
+			t.Errorf("%s: got code %d, want %d", tt.target, rec.Code, tt.code)
+		}
+	}
+	if rec := get(h, valid); rec.Header().Get("Location") != "https://example.com/a" {
+		t.Errorf("%s: got Location %q, want https://example.com/a", valid, rec.Header().Get("Location"))
+	}
+}
+
+func TestSignedEmptySecret(t *testing.T) {
+	if _, err := SignedHandler(map[string]string{"/a": "https://example.com/a"}, nil, http.NotFoundHandler()); err == nil {
+		t.Errorf("SignedHandler: no error for nil secret")
+	}
+	if _, err := SignPath([]byte{}, "/a", time.Now().Add(time.Hour)); err == nil {
+		t.Errorf("SignPath: no error for empty secret")
+	}
+}
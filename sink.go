@@ -0,0 +1,152 @@
+package urlshort
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event describes a single redirect.
+type Event struct {
+	Path        string
+	Destination string
+	Time        time.Time
+	ClientIP    string
+}
+
+// Sink receives an Event for every redirect of a SinkHandler,
+// for example to export clicks to a queue or a webhook.
+type Sink interface {
+	Record(Event)
+}
+
+// SinkHandler is an http.Handler that calls another handler
+// and passes an Event to a Sink for every redirect it responds
+// with. Events are queued and handed to the Sink from a
+// goroutine of their own, so a slow Sink never delays a
+// redirect; events arriving while the queue is full are
+// dropped and counted. The client IP is taken from the
+// request's RemoteAddr. It is safe for concurrent use.
+type SinkHandler struct {
+	// Now returns the time recorded in events. Nil means
+	// time.Now. It must not be changed while h is serving.
+	Now func() time.Time
+
+	h     http.Handler
+	queue *ChannelSink
+}
+
+// defaultSinkQueue is the queue size of a SinkHandler created
+// with a size of zero or less.
+const defaultSinkQueue = 1024
+
+// NewSinkHandler returns a SinkHandler wrapping h and queueing
+// up to size events for sink; zero or less means 1024. Close
+// must be called to stop its goroutine.
+func NewSinkHandler(sink Sink, size int, h http.Handler) *SinkHandler {
+	if size <= 0 {
+		size = defaultSinkQueue
+	}
+	return &SinkHandler{h: h, queue: NewChannelSink(sink, size)}
+}
+
+// ServeHTTP calls the wrapped handler and queues an Event if
+// it redirected.
+func (h *SinkHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec := &statusRecorder{ResponseWriter: w}
+	h.h.ServeHTTP(rec, r)
+
+	if rec.isRedirect() {
+		t := time.Now()
+		if h.Now != nil {
+			t = h.Now()
+		}
+		h.queue.Record(Event{
+			Path:        r.URL.Path,
+			Destination: rec.location(),
+			Time:        t,
+			ClientIP:    clientIP(r, false),
+		})
+	}
+}
+
+// Dropped returns the number of events dropped so far because
+// the queue was full or h was closed.
+func (h *SinkHandler) Dropped() uint64 {
+	return h.queue.Dropped()
+}
+
+// Close stops queueing events and waits until the queued ones
+// have been passed to the Sink. Redirects served afterwards
+// are not recorded. It is idempotent.
+func (h *SinkHandler) Close() {
+	h.queue.Close()
+}
+
+// ChannelSink is a Sink that queues events in a buffered
+// channel and passes them to another Sink from its own
+// goroutine, so that recording never blocks. Events arriving
+// while the buffer is full are dropped and counted. It is safe
+// for concurrent use.
+type ChannelSink struct {
+	events  chan Event
+	sink    Sink
+	dropped atomic.Uint64
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+// NewChannelSink returns a ChannelSink buffering up to size
+// events for sink. Close must be called to stop its goroutine.
+func NewChannelSink(sink Sink, size int) *ChannelSink {
+	s := &ChannelSink{
+		events: make(chan Event, size),
+		sink:   sink,
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Record queues e, or drops it if the buffer is full or s is
+// closed.
+func (s *ChannelSink) Record(e Event) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		s.dropped.Add(1)
+		return
+	}
+	select {
+	case s.events <- e:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of events dropped so far.
+func (s *ChannelSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close stops accepting events and waits until the queued
+// ones have been passed on. It is idempotent.
+func (s *ChannelSink) Close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.events)
+	}
+	s.mu.Unlock()
+	<-s.done
+}
+
+func (s *ChannelSink) run() {
+	defer close(s.done)
+	for e := range s.events {
+		s.sink.Record(e)
+	}
+}
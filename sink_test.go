@@ -0,0 +1,103 @@
+package urlshort
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingSink is a Sink keeping the events it receives. If
+// block is not nil, Record waits until it is closed.
+type recordingSink struct {
+	block chan struct{}
+
+	mu     sync.Mutex
+	events []Event
+}
+
+func (s *recordingSink) Record(e Event) {
+	if s.block != nil {
+		<-s.block
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, e)
+}
+
+func (s *recordingSink) recorded() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Event(nil), s.events...)
+}
+
+func TestSinkHandler(t *testing.T) {
+	sink := &recordingSink{}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := NewSinkHandler(sink, 0, MapHandler(map[string]string{"/a": "https://example.com/a"}, http.NotFoundHandler()))
+	h.Now = func() time.Time { return now }
+
+	if rec := get(h, "/a"); rec.Code != http.StatusFound {
+		t.Errorf("/a: got code %d, want 302", rec.Code)
+	}
+	if rec := get(h, "/b"); rec.Code != http.StatusNotFound {
+		t.Errorf("/b: got code %d, want fallback", rec.Code)
+	}
+	h.Close()
+
+	want := Event{Path: "/a", Destination: "https://example.com/a", Time: now, ClientIP: "192.0.2.1"}
+	if got := sink.recorded(); len(got) != 1 || got[0] != want {
+		t.Errorf("got events %+v, want [%+v]", got, want)
+	}
+	if h.Dropped() != 0 {
+		t.Errorf("got %d dropped events, want none", h.Dropped())
+	}
+}
+
+func TestSinkHandlerSlowSink(t *testing.T) {
+	sink := &recordingSink{block: make(chan struct{})}
+	h := NewSinkHandler(sink, 2, MapHandler(map[string]string{"/a": "https://example.com/a"}, http.NotFoundHandler()))
+
+	// The sink is stuck, so at most one event is being recorded
+	// and two are queued; the rest must be dropped rather than
+	// delay the redirects.
+	for range 10 {
+		if rec := get(h, "/a"); rec.Code != http.StatusFound {
+			t.Fatalf("got code %d, want 302", rec.Code)
+		}
+	}
+	if n := h.Dropped(); n < 7 {
+		t.Errorf("got %d dropped events, want at least 7", n)
+	}
+
+	close(sink.block)
+	h.Close()
+	h.Close()
+	if n := uint64(len(sink.recorded())) + h.Dropped(); n != 10 {
+		t.Errorf("recorded plus dropped events is %d, want 10", n)
+	}
+
+	dropped := h.Dropped()
+	get(h, "/a")
+	if h.Dropped() != dropped+1 {
+		t.Errorf("redirect after Close was not counted as dropped")
+	}
+}
+
+func TestChannelSink(t *testing.T) {
+	sink := &recordingSink{}
+	s := NewChannelSink(sink, 4)
+	for _, path := range []string{"/a", "/b", "/c"} {
+		s.Record(Event{Path: path})
+	}
+	s.Close()
+
+	got := sink.recorded()
+	if len(got) != 3 || got[0].Path != "/a" || got[2].Path != "/c" {
+		t.Errorf("got events %+v, want /a, /b and /c in order", got)
+	}
+	s.Record(Event{Path: "/d"})
+	if s.Dropped() != 1 {
+		t.Errorf("got %d dropped events after Close, want 1", s.Dropped())
+	}
+}
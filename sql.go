@@ -0,0 +1,61 @@
+package urlshort
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+)
+
+// SQLHandler will run the provided query against db and then
+// return an http.HandlerFunc (which also implements
+// http.Handler) that will attempt to map any paths to their
+// corresponding URL. If the path is not returned by the query,
+// then the fallback http.Handler will be called instead.
+//
+// The query must return exactly two columns, path and url, in
+// that order. Paths missing a leading slash get one, as in the
+// config formats. The query is run once when SQLHandler is
+// called, so later changes to the table are not picked up.
+//
+// An error is returned if the query fails or any of its rows
+// cannot be scanned into a pair of strings.
+func SQLHandler(db *sql.DB, query string, fallback http.Handler) (http.HandlerFunc, error) {
+	pathsToUrls, err := loadSql(db, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return MapHandler(pathsToUrls, fallback), nil
+}
+
+func loadSql(db *sql.DB, query string) (map[string]string, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if len(cols) != 2 {
+		return nil, fmt.Errorf("urlshort: query returned %d columns, want 2 (path, url)", len(cols))
+	}
+
+	pathToUrls := make(map[string]string)
+	for rows.Next() {
+		var path, url string
+		if err := rows.Scan(&path, &url); err != nil {
+			return nil, err
+		}
+		pathToUrls[normalizePath(path)] = url
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := checkDestinations(pathToUrls); err != nil {
+		return nil, err
+	}
+	return pathToUrls, nil
+}
@@ -0,0 +1,101 @@
+package urlshort
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// openTestDB returns an in-memory SQLite database holding a
+// redirects table with the given rows of path and url.
+func openTestDB(t *testing.T, rows ...[2]any) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	// Every connection to ":memory:" opens a database of its
+	// own, so keep to one.
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("CREATE TABLE redirects (path TEXT, url TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if _, err := db.Exec("INSERT INTO redirects VALUES (?, ?)", row[0], row[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func TestSQLHandler(t *testing.T) {
+	db := openTestDB(t,
+		[2]any{"/urlshort", "https://github.com/gophercises/urlshort"},
+		[2]any{"/yaml-godoc", "https://godoc.org/gopkg.in/yaml.v2"},
+	)
+	h, err := SQLHandler(db, "SELECT path, url FROM redirects", http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"/urlshort":   "https://github.com/gophercises/urlshort",
+		"/yaml-godoc": "https://godoc.org/gopkg.in/yaml.v2",
+	} {
+		rec := get(h, path)
+		if rec.Code != http.StatusFound || rec.Header().Get("Location") != want {
+			t.Errorf("%s: got %d %q, want 302 %q", path, rec.Code, rec.Header().Get("Location"), want)
+		}
+	}
+	if rec := get(h, "/other"); rec.Code != http.StatusNotFound {
+		t.Errorf("/other: got code %d, want fallback", rec.Code)
+	}
+}
+
+func TestSQLHandlerSnapshot(t *testing.T) {
+	db := openTestDB(t, [2]any{"/a", "https://example.com/a"})
+	h, err := SQLHandler(db, "SELECT path, url FROM redirects", http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO redirects VALUES ('/b', 'https://example.com/b')"); err != nil {
+		t.Fatal(err)
+	}
+	if rec := get(h, "/b"); rec.Code != http.StatusNotFound {
+		t.Errorf("row inserted after SQLHandler returned was served")
+	}
+}
+
+func TestSQLHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		rows  [][2]any
+		query string
+	}{
+		{"bad query", nil, "SELECT nope FROM redirects"},
+		{"one column", nil, "SELECT path FROM redirects"},
+		{"three columns", nil, "SELECT path, url, url FROM redirects"},
+		{"null path", [][2]any{{nil, "https://example.com/"}}, "SELECT path, url FROM redirects"},
+		{"empty url", [][2]any{{"/a", ""}}, "SELECT path, url FROM redirects"},
+	}
+	for _, tt := range tests {
+		db := openTestDB(t, tt.rows...)
+		if _, err := SQLHandler(db, tt.query, http.NotFoundHandler()); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+}
+
+func TestSQLHandlerPathWithoutLeadingSlash(t *testing.T) {
+	db := openTestDB(t, [2]any{"gh", "https://example.com/gh"})
+	h, err := SQLHandler(db, "SELECT path, url FROM redirects", http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := get(h, "/gh").Header().Get("Location"); got != "https://example.com/gh" {
+		t.Errorf("/gh: got Location %q, want https://example.com/gh", got)
+	}
+}
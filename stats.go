@@ -0,0 +1,87 @@
+package urlshort
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// PathStats describes the traffic seen by one path of a
+// StatsHandler.
+type PathStats struct {
+	Count     uint64
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// StatsHandler is an http.Handler that redirects like
+// MapHandler while recording, per path, how many times it was
+// hit and when it was first and last hit. Requests passed to
+// the fallback are recorded under the empty string. It is safe
+// for concurrent use.
+type StatsHandler struct {
+	// Now returns the time recorded for a hit. Nil means
+	// time.Now. It must not be changed while h is serving.
+	Now func() time.Time
+
+	pathsToUrls map[string]string
+	fallback    http.Handler
+
+	mu    sync.Mutex
+	stats map[string]PathStats
+}
+
+// NewStatsHandler returns a StatsHandler serving the paths in
+// pathsToUrls. The map must not be modified afterwards.
+func NewStatsHandler(pathsToUrls map[string]string, fallback http.Handler) *StatsHandler {
+	return &StatsHandler{
+		pathsToUrls: pathsToUrls,
+		fallback:    fallback,
+		stats:       make(map[string]PathStats),
+	}
+}
+
+// ServeHTTP redirects to the URL mapped to the request path,
+// or calls the fallback if there is none.
+func (h *StatsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	if dest, ok := h.pathsToUrls[path]; ok {
+		h.record(path)
+		http.Redirect(w, r, dest, http.StatusFound)
+		return
+	}
+
+	h.record("")
+	h.fallback.ServeHTTP(w, r)
+}
+
+func (h *StatsHandler) record(path string) {
+	t := time.Now()
+	if h.Now != nil {
+		t = h.Now()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.stats[path]
+	if s.Count == 0 {
+		s.FirstSeen = t
+	}
+	s.Count++
+	s.LastSeen = t
+	h.stats[path] = s
+}
+
+// Snapshot returns a copy of the stats of every path that has
+// been hit at least once.
+func (h *StatsHandler) Snapshot() map[string]PathStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	snapshot := make(map[string]PathStats, len(h.stats))
+	for path, s := range h.stats {
+		snapshot[path] = s
+	}
+	return snapshot
+}
@@ -0,0 +1,65 @@
+package urlshort
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStatsHandler(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	h := NewStatsHandler(map[string]string{"/a": "https://example.com/a"}, http.NotFoundHandler())
+	h.Now = func() time.Time { return now }
+
+	if rec := get(h, "/a"); rec.Code != http.StatusFound || rec.Header().Get("Location") != "https://example.com/a" {
+		t.Errorf("/a: got %d %q, want 302 https://example.com/a", rec.Code, rec.Header().Get("Location"))
+	}
+	now = start.Add(time.Hour)
+	get(h, "/a")
+	if rec := get(h, "/other"); rec.Code != http.StatusNotFound {
+		t.Errorf("/other: got code %d, want fallback", rec.Code)
+	}
+
+	got := h.Snapshot()
+	want := map[string]PathStats{
+		"/a": {Count: 2, FirstSeen: start, LastSeen: start.Add(time.Hour)},
+		"":   {Count: 1, FirstSeen: start.Add(time.Hour), LastSeen: start.Add(time.Hour)},
+	}
+	if len(got) != len(want) {
+		t.Errorf("got stats for %d paths, want %d", len(got), len(want))
+	}
+	for path, w := range want {
+		if got[path] != w {
+			t.Errorf("%q: got %+v, want %+v", path, got[path], w)
+		}
+	}
+}
+
+func TestStatsHandlerSnapshotIsCopy(t *testing.T) {
+	h := NewStatsHandler(map[string]string{"/a": "https://example.com/a"}, http.NotFoundHandler())
+	get(h, "/a")
+	s := h.Snapshot()
+	get(h, "/a")
+	if s["/a"].Count != 1 {
+		t.Errorf("snapshot changed after a later hit: count %d", s["/a"].Count)
+	}
+}
+
+func TestStatsHandlerConcurrent(t *testing.T) {
+	h := NewStatsHandler(map[string]string{"/a": "https://example.com/a"}, http.NotFoundHandler())
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 100 {
+				get(h, "/a")
+				h.Snapshot()
+			}
+		})
+	}
+	wg.Wait()
+	if n := h.Snapshot()["/a"].Count; n != 800 {
+		t.Errorf("got count %d, want 800", n)
+	}
+}
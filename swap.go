@@ -0,0 +1,71 @@
+package urlshort
+
+import (
+	"maps"
+	"net/http"
+	"sync/atomic"
+)
+
+// SwapHandler is an http.Handler that redirects paths to URLs
+// like MapHandler, but whose whole mapping can be replaced
+// while it is serving. Lookups never take a lock: each mapping
+// is copied into a private map that is never modified again,
+// and Swap atomically publishes the new one. This suits large
+// mappings that are rebuilt as a whole, for example on reload,
+// better than DynamicHandler. It is safe for concurrent use.
+type SwapHandler struct {
+	// AllowedHosts, if not empty, restricts redirects to
+	// destinations on these hosts, as Options.AllowedHosts
+	// does for MapHandlerWithOptions; requests for paths mapped
+	// elsewhere are passed to the fallback. It must not be
+	// changed while h is in use.
+	AllowedHosts []string
+
+	pathsToUrls atomic.Pointer[map[string]string]
+	fallback    http.Handler
+}
+
+// NewSwapHandler returns a SwapHandler serving pathsToUrls.
+// Paths without a mapping are passed to the fallback
+// http.Handler.
+func NewSwapHandler(pathsToUrls map[string]string, fallback http.Handler) *SwapHandler {
+	h := &SwapHandler{fallback: fallback}
+	h.Swap(pathsToUrls)
+	return h
+}
+
+// Swap replaces the mapping with a copy of newMap. Requests
+// already being served finish with the old mapping.
+func (h *SwapHandler) Swap(newMap map[string]string) {
+	frozen := maps.Clone(newMap)
+	if frozen == nil {
+		frozen = make(map[string]string)
+	}
+	h.pathsToUrls.Store(&frozen)
+}
+
+// Paths returns the currently mapped paths in sorted order.
+func (h *SwapHandler) Paths() []string {
+	return sortedKeys(*h.pathsToUrls.Load())
+}
+
+// PathsPage returns the currently mapped paths starting with
+// prefix, in sorted order, skipping the first offset and
+// returning at most limit of them, along with the total number
+// of paths starting with prefix. A limit of zero or less means
+// no limit. An offset past the end gives an empty page.
+func (h *SwapHandler) PathsPage(prefix string, offset, limit int) ([]string, int) {
+	return pagePaths(sortedKeys(*h.pathsToUrls.Load()), prefix, offset, limit)
+}
+
+// ServeHTTP redirects to the URL mapped to the request path,
+// or calls the fallback if there is none.
+func (h *SwapHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	dest, ok := (*h.pathsToUrls.Load())[r.URL.Path]
+	if ok && (len(h.AllowedHosts) == 0 || hostAllowed(dest, h.AllowedHosts)) {
+		http.Redirect(w, r, dest, http.StatusFound)
+		return
+	}
+
+	h.fallback.ServeHTTP(w, r)
+}
@@ -0,0 +1,137 @@
+package urlshort
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestSwapHandler(t *testing.T) {
+	m := map[string]string{"/a": "https://example.com/a"}
+	h := NewSwapHandler(m, http.NotFoundHandler())
+	m["/a"] = "https://example.com/changed"
+	if got := get(h, "/a").Header().Get("Location"); got != "https://example.com/a" {
+		t.Errorf("/a: got Location %q after changing the source map, want the original", got)
+	}
+
+	h.Swap(map[string]string{"/b": "https://example.com/b"})
+	if rec := get(h, "/a"); rec.Code != http.StatusNotFound {
+		t.Errorf("/a: got code %d after Swap, want fallback", rec.Code)
+	}
+	if got := get(h, "/b").Header().Get("Location"); got != "https://example.com/b" {
+		t.Errorf("/b: got Location %q after Swap, want https://example.com/b", got)
+	}
+
+	h.Swap(nil)
+	if rec := get(h, "/b"); rec.Code != http.StatusNotFound {
+		t.Errorf("/b: got code %d after Swap(nil), want fallback", rec.Code)
+	}
+}
+
+func TestSwapHandlerConcurrentSwap(t *testing.T) {
+	h := NewSwapHandler(map[string]string{"/a": "https://example.com/0"}, http.NotFoundHandler())
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			for i := range 200 {
+				h.Swap(map[string]string{"/a": fmt.Sprintf("https://example.com/%d", i)})
+			}
+		})
+		wg.Go(func() {
+			for range 200 {
+				if rec := get(h, "/a"); rec.Code != http.StatusFound {
+					t.Errorf("/a: got code %d during Swap, want 302", rec.Code)
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// benchmarkPaths is the number of paths mapped by the lookup
+// benchmarks.
+const benchmarkPaths = 10000
+
+func benchmarkMap() map[string]string {
+	m := make(map[string]string, benchmarkPaths)
+	for i := range benchmarkPaths {
+		m[fmt.Sprintf("/p%d", i)] = fmt.Sprintf("https://example.com/%d", i)
+	}
+	return m
+}
+
+// benchmarkParallelHits serves hits to mapped paths with h from
+// every benchmark goroutine at once.
+func benchmarkParallelHits(b *testing.B, h http.Handler) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		requests := make([]*http.Request, 64)
+		for i := range requests {
+			requests[i] = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/p%d", i*151%benchmarkPaths), nil)
+		}
+		w := &discardWriter{header: make(http.Header)}
+		for i := 0; pb.Next(); i++ {
+			h.ServeHTTP(w, requests[i%len(requests)])
+		}
+	})
+}
+
+func BenchmarkSwapHandlerLookup(b *testing.B) {
+	benchmarkParallelHits(b, NewSwapHandler(benchmarkMap(), http.NotFoundHandler()))
+}
+
+func BenchmarkDynamicHandlerLookup(b *testing.B) {
+	h := NewDynamicHandler(http.NotFoundHandler())
+	for path, url := range benchmarkMap() {
+		h.Set(path, url)
+	}
+	benchmarkParallelHits(b, h)
+}
+
+func BenchmarkMapHandlerLookup(b *testing.B) {
+	benchmarkParallelHits(b, MapHandler(benchmarkMap(), http.NotFoundHandler()))
+}
+
+func TestSwapHandlerPaths(t *testing.T) {
+	h := NewSwapHandler(map[string]string{
+		"/b": "https://example.com/b",
+		"/a": "https://example.com/a",
+	}, http.NotFoundHandler())
+	if got, want := h.Paths(), []string{"/a", "/b"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	h.Swap(map[string]string{"/c": "https://example.com/c"})
+	if got, want := h.Paths(), []string{"/c"}; !slices.Equal(got, want) {
+		t.Errorf("after Swap: got %v, want %v", got, want)
+	}
+}
+
+func TestSwapHandlerPathsPage(t *testing.T) {
+	h := NewSwapHandler(map[string]string{
+		"/a":      "https://example.com/a",
+		"/blog/1": "https://example.com/1",
+		"/blog/2": "https://example.com/2",
+	}, http.NotFoundHandler())
+	page, total := h.PathsPage("/blog/", 1, 1)
+	if !slices.Equal(page, []string{"/blog/2"}) || total != 2 {
+		t.Errorf("got %v of %d, want [/blog/2] of 2", page, total)
+	}
+}
+
+func TestSwapHandlerAllowedHosts(t *testing.T) {
+	h := NewSwapHandler(map[string]string{
+		"/a":    "https://example.com/a",
+		"/evil": "https://evil.example/",
+	}, http.NotFoundHandler())
+	h.AllowedHosts = []string{"example.com"}
+	if got := get(h, "/a").Header().Get("Location"); got != "https://example.com/a" {
+		t.Errorf("/a: got Location %q, want https://example.com/a", got)
+	}
+	if rec := get(h, "/evil"); rec.Code != http.StatusNotFound {
+		t.Errorf("/evil: got code %d, want fallback", rec.Code)
+	}
+}
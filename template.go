@@ -0,0 +1,199 @@
+package urlshort
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// TemplateHandler will return an http.HandlerFunc (which also
+// implements http.Handler) that redirects request paths
+// matching one of the patterns (keys in the map) to its
+// destination (the values), with placeholders filled in from
+// the path. If no pattern matches, then the fallback
+// http.Handler will be called instead.
+//
+// Patterns use the syntax of http.ServeMux: a segment written
+// as {name} matches any single non-empty path segment, and a
+// final segment written as {name...} matches the rest of the
+// path, including any slashes. Other segments must match
+// literally. Destinations refer to the captures as {name}, so
+// "/docs/{rest...}" to "https://newdocs.example.com/{rest}"
+// sends "/docs/a/b" to "https://newdocs.example.com/a/b".
+// Captures are path-escaped and lose any leading slashes
+// before they are filled in, so that a request cannot add a
+// query or fragment to the destination or, through a
+// destination such as "/{rest}", send it to another host.
+//
+// When several patterns match, the one with the most literal
+// segments wins, then one without a {name...} segment; any
+// remaining tie goes to the pattern that sorts first.
+//
+// An error is returned if a pattern is malformed or if a
+// destination refers to a name its pattern does not capture.
+func TemplateHandler(templates map[string]string, fallback http.Handler) (http.HandlerFunc, error) {
+	compiled := make([]pathTemplate, 0, len(templates))
+	for _, pattern := range sortedKeys(templates) {
+		t, err := parseTemplate(pattern, templates[pattern])
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, t)
+	}
+	sort.SliceStable(compiled, func(i, j int) bool {
+		a, b := compiled[i], compiled[j]
+		if a.literals != b.literals {
+			return a.literals > b.literals
+		}
+		return !a.tail && b.tail
+	})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		for _, t := range compiled {
+			if dest, ok := t.expand(r.URL.Path); ok {
+				http.Redirect(w, r, dest, http.StatusFound)
+				return
+			}
+		}
+
+		fallback.ServeHTTP(w, r)
+	}, nil
+}
+
+// templateSegment is one segment of a pattern: either a
+// literal, or a capture when name is set.
+type templateSegment struct {
+	literal string
+	name    string
+}
+
+// templatePart is a piece of a destination: either literal
+// text, or a placeholder when name is set.
+type templatePart struct {
+	text string
+	name string
+}
+
+type pathTemplate struct {
+	segments []templateSegment
+	dest     []templatePart
+	literals int
+	tail     bool
+}
+
+func parseTemplate(pattern, dest string) (pathTemplate, error) {
+	var t pathTemplate
+	if !strings.HasPrefix(pattern, "/") {
+		return t, fmt.Errorf("urlshort: pattern %q does not start with /", pattern)
+	}
+
+	captures := make(map[string]bool)
+	segments := strings.Split(pattern[1:], "/")
+	for i, seg := range segments {
+		if !strings.HasPrefix(seg, "{") {
+			if strings.ContainsAny(seg, "{}") {
+				return t, fmt.Errorf("urlshort: pattern %q: bad segment %q", pattern, seg)
+			}
+			t.segments = append(t.segments, templateSegment{literal: seg})
+			t.literals++
+			continue
+		}
+
+		name, ok := strings.CutSuffix(seg[1:], "}")
+		if !ok {
+			return t, fmt.Errorf("urlshort: pattern %q: bad segment %q", pattern, seg)
+		}
+		if name, ok = strings.CutSuffix(name, "..."); ok {
+			if i != len(segments)-1 {
+				return t, fmt.Errorf("urlshort: pattern %q: {%s...} must be the last segment", pattern, name)
+			}
+			t.tail = true
+		}
+		if !isTemplateName(name) {
+			return t, fmt.Errorf("urlshort: pattern %q: bad name %q", pattern, name)
+		}
+		if captures[name] {
+			return t, fmt.Errorf("urlshort: pattern %q: duplicate name %q", pattern, name)
+		}
+		captures[name] = true
+		t.segments = append(t.segments, templateSegment{name: name})
+	}
+
+	rest := dest
+	for rest != "" {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			t.dest = append(t.dest, templatePart{text: rest})
+			break
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return t, fmt.Errorf("urlshort: destination %q for %s: unclosed {", dest, pattern)
+		}
+		name := rest[open+1 : open+end]
+		if !captures[name] {
+			return t, fmt.Errorf("urlshort: destination %q for %s: unknown placeholder {%s}", dest, pattern, name)
+		}
+		if open > 0 {
+			t.dest = append(t.dest, templatePart{text: rest[:open]})
+		}
+		t.dest = append(t.dest, templatePart{name: name})
+		rest = rest[open+end+1:]
+	}
+	return t, nil
+}
+
+func isTemplateName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if c != '_' && (c < '0' || c > '9') && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// expand matches path against the pattern and returns the
+// destination with its placeholders filled in.
+func (t pathTemplate) expand(path string) (string, bool) {
+	if !strings.HasPrefix(path, "/") {
+		return "", false
+	}
+	rest := path[1:]
+	values := make(map[string]string, len(t.segments)-t.literals)
+	for i, seg := range t.segments {
+		if t.tail && i == len(t.segments)-1 {
+			values[seg.name] = escapePathPart(rest)
+			break
+		}
+
+		part, next, found := strings.Cut(rest, "/")
+		if found != (i < len(t.segments)-1) {
+			return "", false
+		}
+		switch {
+		case seg.name == "":
+			if part != seg.literal {
+				return "", false
+			}
+		case part == "":
+			return "", false
+		default:
+			values[seg.name] = escapePathPart(part)
+		}
+		rest = next
+	}
+
+	var b strings.Builder
+	for _, p := range t.dest {
+		if p.name != "" {
+			b.WriteString(values[p.name])
+		} else {
+			b.WriteString(p.text)
+		}
+	}
+	return b.String(), true
+}
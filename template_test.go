@@ -0,0 +1,78 @@
+package urlshort
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestTemplateHandler(t *testing.T) {
+	h, err := TemplateHandler(map[string]string{
+		"/docs/{rest...}": "https://newdocs.example.com/{rest}",
+		"/u/{id}":         "https://example.com/users/{id}/profile",
+		"/u/me":           "https://example.com/me",
+		"/r/{a}/{b}":      "https://example.com/{b}-{a}",
+	}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"/docs/a/b": "https://newdocs.example.com/a/b",
+		"/docs/":    "https://newdocs.example.com/",
+		"/u/42":     "https://example.com/users/42/profile",
+		"/u/me":     "https://example.com/me",
+		"/r/1/2":    "https://example.com/2-1",
+	} {
+		if got := get(h, path).Header().Get("Location"); got != want {
+			t.Errorf("%s: got Location %q, want %q", path, got, want)
+		}
+	}
+	for _, path := range []string{"/u", "/u/1/2", "/r/1", "/other"} {
+		if rec := get(h, path); rec.Code != http.StatusNotFound {
+			t.Errorf("%s: got code %d, want fallback", path, rec.Code)
+		}
+	}
+}
+
+func TestTemplateHandlerEscapesCaptures(t *testing.T) {
+	h, err := TemplateHandler(map[string]string{
+		"/docs/{rest...}": "https://newdocs.example.com/{rest}",
+		"/u/{id}":         "https://example.com/users/{id}",
+		"/old/{rest...}":  "/{rest}",
+	}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"/docs/a%3Fadmin=1": "https://newdocs.example.com/a%3Fadmin=1",
+		"/docs/a%23x":       "https://newdocs.example.com/a%23x",
+		"/docs/a%20b":       "https://newdocs.example.com/a%20b",
+		"/docs//evil.com/x": "https://newdocs.example.com/evil.com/x",
+		"/u/1%3Fx%23y":      "https://example.com/users/1%3Fx%23y",
+		"/old//evil.com/x":  "/evil.com/x",
+		"/old/%5Cevil.com":  "/%5Cevil.com",
+	} {
+		if got := get(h, path).Header().Get("Location"); got != want {
+			t.Errorf("%s: got Location %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestTemplateHandlerInvalid(t *testing.T) {
+	tests := []struct {
+		pattern, dest, want string
+	}{
+		{"/a/{x}", "https://example.com/{y}", "unknown placeholder"},
+		{"/a/{x...}/b", "https://example.com/", "last segment"},
+		{"/a/{x}/{x}", "https://example.com/", "duplicate name"},
+		{"/a/{x", "https://example.com/", "bad segment"},
+		{"/a/{x}", "https://example.com/{x", "unclosed"},
+		{"a", "https://example.com/", "does not start with /"},
+	}
+	for _, tt := range tests {
+		_, err := TemplateHandler(map[string]string{tt.pattern: tt.dest}, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s -> %s: got error %v, want one containing %q", tt.pattern, tt.dest, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Package urlshortbolt serves redirects stored in a bbolt
+// database.
+package urlshortbolt
+
+import (
+	"fmt"
+	"net/http"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/kapeluszk/urlshort"
+)
+
+// Handler will load every key/value pair in the named bucket
+// of db as a path and its URL, and then return an
+// http.HandlerFunc (which also implements http.Handler) that
+// will attempt to map any paths to their corresponding URL.
+// If the path is not in the bucket, then the fallback
+// http.Handler will be called instead.
+//
+// Keys missing a leading slash get one, as in the config
+// formats. The bucket is read once when Handler is called. An
+// error is returned if the bucket does not exist.
+//
+// See Put to store entries in the bucket.
+func Handler(db *bolt.DB, bucket string, fallback http.Handler) (http.HandlerFunc, error) {
+	pathsToUrls, err := load(db, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	return urlshort.MapHandler(pathsToUrls, fallback), nil
+}
+
+// Put stores a mapping from path to url in the named bucket of
+// db, creating the bucket if needed and replacing any existing
+// entry for path.
+func Put(db *bolt.DB, bucket, path, url string) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(path), []byte(url))
+	})
+}
+
+func load(db *bolt.DB, bucket string) (map[string]string, error) {
+	pathToUrls := make(map[string]string)
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return fmt.Errorf("urlshort: bucket %q not found", bucket)
+		}
+		return b.ForEach(func(k, v []byte) error {
+			pathToUrls[string(k)] = string(v)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return urlshort.NormalizeMap(pathToUrls)
+}
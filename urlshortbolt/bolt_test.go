@@ -0,0 +1,89 @@
+package urlshortbolt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func openTestDB(t *testing.T) *bolt.DB {
+	t.Helper()
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "redirects.db"), 0o600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func get(h http.Handler, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func TestHandler(t *testing.T) {
+	db := openTestDB(t)
+	for path, url := range map[string]string{
+		"/urlshort":   "https://github.com/gophercises/urlshort",
+		"/yaml-godoc": "https://godoc.org/gopkg.in/yaml.v2",
+	} {
+		if err := Put(db, "redirects", path, url); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Put(db, "redirects", "/urlshort", "https://example.com/replaced"); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := Handler(db, "redirects", http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"/urlshort":   "https://example.com/replaced",
+		"/yaml-godoc": "https://godoc.org/gopkg.in/yaml.v2",
+	} {
+		rec := get(h, path)
+		if rec.Code != http.StatusFound || rec.Header().Get("Location") != want {
+			t.Errorf("%s: got %d %q, want 302 %q", path, rec.Code, rec.Header().Get("Location"), want)
+		}
+	}
+	if rec := get(h, "/other"); rec.Code != http.StatusNotFound {
+		t.Errorf("/other: got code %d, want fallback", rec.Code)
+	}
+}
+
+func TestHandlerMissingBucket(t *testing.T) {
+	db := openTestDB(t)
+	if _, err := Handler(db, "redirects", http.NotFoundHandler()); err == nil {
+		t.Error("missing bucket accepted")
+	}
+}
+
+func TestHandlerEmptyURL(t *testing.T) {
+	db := openTestDB(t)
+	if err := Put(db, "redirects", "/a", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Handler(db, "redirects", http.NotFoundHandler()); err == nil {
+		t.Error("empty url accepted")
+	}
+}
+
+func TestHandlerPathWithoutLeadingSlash(t *testing.T) {
+	db := openTestDB(t)
+	if err := Put(db, "redirects", "gh", "https://example.com/gh"); err != nil {
+		t.Fatal(err)
+	}
+	h, err := Handler(db, "redirects", http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := get(h, "/gh").Header().Get("Location"); got != "https://example.com/gh" {
+		t.Errorf("/gh: got Location %q, want https://example.com/gh", got)
+	}
+}
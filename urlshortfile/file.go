@@ -0,0 +1,145 @@
+// Package urlshortfile serves redirects from a config file that
+// is reloaded whenever it changes.
+package urlshortfile
+
+import (
+	"log"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/kapeluszk/urlshort"
+)
+
+// Handler will load the YAML file at path and then return an
+// http.Handler that will attempt to map any paths to their
+// corresponding URL, just like urlshort.YAMLHandler. If the
+// path is not provided in the YAML, then the fallback
+// http.Handler will be called instead.
+//
+// The file may be gzipped, in which case it is decompressed
+// transparently. It is watched for changes and reloaded
+// whenever it is written or replaced. If a reload fails, the
+// error is logged and the last successfully loaded mapping
+// keeps being served.
+//
+// The watcher runs in its own goroutine until Close is called
+// on the returned Watcher.
+//
+// An error is returned if the file cannot be read or parsed
+// initially, or if it cannot be watched.
+func Handler(path string, fallback http.Handler) (*Watcher, error) {
+	h := &Watcher{
+		path:    filepath.Clean(path),
+		handler: urlshort.NewReloadableHandler(fallback),
+		done:    make(chan struct{}),
+	}
+	if err := h.load(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// Watch the directory rather than the file itself so that
+	// editors replacing the file via rename are noticed too.
+	if err := watcher.Add(filepath.Dir(h.path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	h.watcher = watcher
+	go h.watch()
+
+	return h, nil
+}
+
+// Watcher is the http.Handler returned by Handler. It serves
+// the most recently loaded version of its file.
+type Watcher struct {
+	path    string
+	handler *urlshort.ReloadableHandler
+
+	mu      sync.Mutex
+	loadErr error
+
+	watcher   *fsnotify.Watcher
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// ServeHTTP serves the request with the current mapping.
+func (h *Watcher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.handler.ServeHTTP(w, r)
+}
+
+// Close stops watching the file and waits for the watcher
+// goroutine to exit. The handler keeps serving the last loaded
+// mapping afterwards. Close is idempotent; later calls return
+// the result of the first.
+func (h *Watcher) Close() error {
+	h.closeOnce.Do(func() {
+		h.closeErr = h.watcher.Close()
+		<-h.done
+	})
+	return h.closeErr
+}
+
+// LastLoadError returns the error of the most recent attempt
+// to load the file, or nil if it succeeded.
+func (h *Watcher) LastLoadError() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.loadErr
+}
+
+func (h *Watcher) load() error {
+	err := h.loadFile()
+	h.mu.Lock()
+	h.loadErr = err
+	h.mu.Unlock()
+	return err
+}
+
+func (h *Watcher) loadFile() error {
+	return h.handler.ReloadFile("yaml", h.path)
+}
+
+// reloadDelay is how long the watcher waits for writes to the
+// file to settle before reloading it, so that a file being
+// truncated and rewritten is not loaded half-written.
+const reloadDelay = 100 * time.Millisecond
+
+func (h *Watcher) watch() {
+	defer close(h.done)
+
+	timer := time.NewTimer(reloadDelay)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case ev, ok := <-h.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(ev.Name) != h.path || !ev.Has(fsnotify.Write) && !ev.Has(fsnotify.Create) {
+				continue
+			}
+			timer.Reset(reloadDelay)
+		case <-timer.C:
+			if err := h.load(); err != nil {
+				log.Printf("urlshort: reloading %s: %v", h.path, err)
+			}
+		case err, ok := <-h.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("urlshort: watching %s: %v", h.path, err)
+		}
+	}
+}
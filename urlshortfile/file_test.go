@@ -0,0 +1,150 @@
+package urlshortfile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func get(h http.Handler, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitFor polls cond until it holds, failing the test after a
+// few seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func newTestWatcher(t *testing.T, path string) *Watcher {
+	t.Helper()
+	h, err := Handler(path, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.Close() })
+	return h
+}
+
+func TestHandlerReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "redirects.yaml")
+	writeFile(t, path, "- path: /a\n  url: https://example.com/a\n")
+	h := newTestWatcher(t, path)
+
+	if rec := get(h, "/a"); rec.Code != http.StatusFound {
+		t.Fatalf("/a: got code %d, want 302", rec.Code)
+	}
+
+	writeFile(t, path, "- path: /b\n  url: https://example.com/b\n")
+	waitFor(t, "the rewritten file", func() bool { return get(h, "/b").Code == http.StatusFound })
+	if rec := get(h, "/a"); rec.Code != http.StatusNotFound {
+		t.Errorf("/a: still served after being removed from the file")
+	}
+}
+
+func TestHandlerReloadsRenamedFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "redirects.yaml")
+	writeFile(t, path, "- path: /a\n  url: https://example.com/a\n")
+	h := newTestWatcher(t, path)
+
+	tmp := filepath.Join(dir, "redirects.yaml.tmp")
+	writeFile(t, tmp, "- path: /b\n  url: https://example.com/b\n")
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the replaced file", func() bool { return get(h, "/b").Code == http.StatusFound })
+}
+
+func TestHandlerKeepsLastGoodMapping(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "redirects.yaml")
+	writeFile(t, path, "- path: /a\n  url: https://example.com/a\n")
+	h := newTestWatcher(t, path)
+
+	writeFile(t, path, "- path: [")
+	waitFor(t, "the failed reload", func() bool { return h.LastLoadError() != nil })
+	if rec := get(h, "/a"); rec.Code != http.StatusFound {
+		t.Errorf("/a: got code %d after a failed reload, want the last mapping", rec.Code)
+	}
+}
+
+func TestHandlerInvalidInitialFile(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Handler(filepath.Join(dir, "missing.yaml"), http.NotFoundHandler()); err == nil {
+		t.Error("missing file accepted")
+	}
+
+	path := filepath.Join(dir, "bad.yaml")
+	writeFile(t, path, "- path: [")
+	if _, err := Handler(path, http.NotFoundHandler()); err == nil {
+		t.Error("malformed file accepted")
+	}
+}
+
+func TestCloseStopsWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "redirects.yaml")
+	writeFile(t, path, "- path: /a\n  url: https://example.com/a\n")
+
+	before := runtime.NumGoroutine()
+	h, err := Handler(path, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	waitFor(t, "the watcher goroutines to exit", func() bool { return runtime.NumGoroutine() <= before })
+
+	if rec := get(h, "/a"); rec.Code != http.StatusFound {
+		t.Errorf("/a: got code %d after Close, want the last mapping", rec.Code)
+	}
+	writeFile(t, path, "- path: /b\n  url: https://example.com/b\n")
+	time.Sleep(2 * reloadDelay)
+	if rec := get(h, "/b"); rec.Code != http.StatusNotFound {
+		t.Errorf("/b: file reloaded after Close")
+	}
+}
+
+func TestHandlerGzippedFile(t *testing.T) {
+	gz := func(data string) string {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write([]byte(data))
+		zw.Close()
+		return buf.String()
+	}
+	path := filepath.Join(t.TempDir(), "redirects.yaml.gz")
+	writeFile(t, path, gz("- path: /a\n  url: https://example.com/a\n"))
+	h := newTestWatcher(t, path)
+
+	if rec := get(h, "/a"); rec.Code != http.StatusFound {
+		t.Fatalf("/a: got code %d, want 302", rec.Code)
+	}
+	writeFile(t, path, gz("- path: /b\n  url: https://example.com/b\n"))
+	waitFor(t, "the rewritten file", func() bool { return get(h, "/b").Code == http.StatusFound })
+}
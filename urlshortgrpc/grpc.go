@@ -0,0 +1,36 @@
+// Package urlshortgrpc serves redirects over gRPC.
+package urlshortgrpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/kapeluszk/urlshort/urlshortpb"
+)
+
+// ResolverServer implements the urlshortpb.Resolver gRPC
+// service on top of a mapping of paths to urls, such as one
+// returned by urlshort.ParseYAML or urlshort.ParseJSON.
+// Register it with urlshortpb.RegisterResolverServer.
+type ResolverServer struct {
+	urlshortpb.UnimplementedResolverServer
+	pathsToUrls map[string]string
+}
+
+// NewResolverServer returns a ResolverServer serving
+// pathsToUrls. The map must not be modified afterwards.
+func NewResolverServer(pathsToUrls map[string]string) *ResolverServer {
+	return &ResolverServer{pathsToUrls: pathsToUrls}
+}
+
+// Resolve returns the destination of req's path, or an error
+// with code codes.NotFound if it has none.
+func (s *ResolverServer) Resolve(ctx context.Context, req *urlshortpb.ResolveRequest) (*urlshortpb.ResolveResponse, error) {
+	dest, ok := s.pathsToUrls[req.GetPath()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no url for path %q", req.GetPath())
+	}
+	return &urlshortpb.ResolveResponse{Url: dest}, nil
+}
@@ -0,0 +1,57 @@
+package urlshortgrpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/kapeluszk/urlshort/urlshortpb"
+)
+
+// newTestClient serves s over an in-memory connection and
+// returns a client for it.
+func newTestClient(t *testing.T, s urlshortpb.ResolverServer) urlshortpb.ResolverClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 16)
+	srv := grpc.NewServer()
+	urlshortpb.RegisterResolverServer(srv, s)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	cc, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cc.Close() })
+	return urlshortpb.NewResolverClient(cc)
+}
+
+func TestResolve(t *testing.T) {
+	c := newTestClient(t, NewResolverServer(map[string]string{
+		"/urlshort": "https://github.com/gophercises/urlshort",
+	}))
+
+	resp, err := c.Resolve(context.Background(), &urlshortpb.ResolveRequest{Path: "/urlshort"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetUrl() != "https://github.com/gophercises/urlshort" {
+		t.Errorf("/urlshort: got url %q, want https://github.com/gophercises/urlshort", resp.GetUrl())
+	}
+
+	_, err = c.Resolve(context.Background(), &urlshortpb.ResolveRequest{Path: "/other"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("/other: got error %v, want code NotFound", err)
+	}
+}
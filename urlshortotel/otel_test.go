@@ -0,0 +1,101 @@
+package urlshortotel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kapeluszk/urlshort"
+)
+
+func get(h http.Handler, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func newTestTracer(t *testing.T) (trace.Tracer, *tracetest.SpanRecorder) {
+	t.Helper()
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	t.Cleanup(func() { tp.Shutdown(t.Context()) })
+	return tp.Tracer("urlshortotel_test"), sr
+}
+
+// spanAttrs returns the attributes of span as strings.
+func spanAttrs(span sdktrace.ReadOnlySpan) map[string]string {
+	attrs := make(map[string]string)
+	for _, a := range span.Attributes() {
+		attrs[string(a.Key)] = a.Value.Emit()
+	}
+	return attrs
+}
+
+func TestHandler(t *testing.T) {
+	tracer, sr := newTestTracer(t)
+	h := Handler(tracer, urlshort.MapHandler(map[string]string{"/a": "https://example.com/a"}, http.NotFoundHandler()))
+	get(h, "/a")
+	get(h, "/b")
+
+	spans := sr.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	tests := []struct {
+		name, destination, status string
+	}{
+		{"/a", "https://example.com/a", "302"},
+		{"/b", "", "404"},
+	}
+	for i, tt := range tests {
+		span := spans[i]
+		if span.Name() != tt.name {
+			t.Errorf("span %d: got name %q, want %q", i, span.Name(), tt.name)
+		}
+		if span.SpanKind() != trace.SpanKindServer {
+			t.Errorf("%s: got kind %v, want server", tt.name, span.SpanKind())
+		}
+		attrs := spanAttrs(span)
+		if attrs["urlshort.destination"] != tt.destination {
+			t.Errorf("%s: got destination %q, want %q", tt.name, attrs["urlshort.destination"], tt.destination)
+		}
+		if attrs["http.response.status_code"] != tt.status {
+			t.Errorf("%s: got status %q, want %q", tt.name, attrs["http.response.status_code"], tt.status)
+		}
+	}
+}
+
+func TestHandlerJoinsCallerTrace(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	tracer, sr := newTestTracer(t)
+	h := Handler(tracer, http.NotFoundHandler())
+	r := httptest.NewRequest(http.MethodGet, "/a", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := sr.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	if got := spans[0].SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("got trace ID %s, want the caller's", got)
+	}
+	if got := spans[0].Parent().SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("got parent span ID %s, want the caller's", got)
+	}
+}
+
+func TestHandlerNilTracer(t *testing.T) {
+	if rec := get(Handler(nil, http.NotFoundHandler()), "/a"); rec.Code != http.StatusNotFound {
+		t.Errorf("got code %d, want the wrapped handler's", rec.Code)
+	}
+}
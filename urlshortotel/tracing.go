@@ -0,0 +1,41 @@
+// Package urlshortotel traces the handlers of package urlshort
+// with OpenTelemetry.
+package urlshortotel
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kapeluszk/urlshort"
+)
+
+// Handler returns an http.Handler that calls h inside a span
+// started with tracer and named after the request path. The
+// span records the redirect destination, if any, and the
+// response status. Trace context in the request headers is
+// extracted with the global propagator, so the span joins the
+// caller's trace. If tracer is nil, h is returned unchanged.
+func Handler(tracer trace.Tracer, h http.Handler) http.Handler {
+	if tracer == nil {
+		return h
+	}
+
+	observed := urlshort.ObserveHandler(func(r *http.Request, status int, location string) {
+		trace.SpanFromContext(r.Context()).SetAttributes(
+			attribute.String("urlshort.destination", location),
+			attribute.Int("http.response.status_code", status),
+		)
+	}, h)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.URL.Path, trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+
+		observed.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
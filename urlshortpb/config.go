@@ -0,0 +1,42 @@
+package urlshortpb
+
+import (
+	"fmt"
+	"net/http"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/kapeluszk/urlshort"
+)
+
+// ConfigHandler will unmarshal the provided binary Config
+// message and then return an http.HandlerFunc (which also
+// implements http.Handler) that will attempt to map any paths
+// to their corresponding URL. If the path is not provided in
+// the message, then the fallback http.Handler will be called
+// instead.
+//
+// An error is returned if the message is corrupt or has an
+// entry with an empty url.
+func ConfigHandler(data []byte, fallback http.Handler) (http.HandlerFunc, error) {
+	pathsToUrls, err := ParseConfig(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return urlshort.MapHandler(pathsToUrls, fallback), nil
+}
+
+// ParseConfig is like urlshort.ParseYAML, but for the binary
+// Config messages accepted by ConfigHandler.
+func ParseConfig(data []byte) (map[string]string, error) {
+	var cfg Config
+	if err := proto.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("urlshort: invalid protobuf config: %w", err)
+	}
+	pathToUrls := make(map[string]string)
+	for _, pu := range cfg.GetPaths() {
+		pathToUrls[pu.GetPath()] = pu.GetUrl()
+	}
+	return urlshort.NormalizeMap(pathToUrls)
+}
@@ -0,0 +1,71 @@
+package urlshortpb
+
+import (
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func get(h http.Handler, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func marshalConfig(t *testing.T, paths ...*PathUrl) []byte {
+	t.Helper()
+	data, err := proto.Marshal(&Config{Paths: paths})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestConfigHandler(t *testing.T) {
+	data := marshalConfig(t,
+		&PathUrl{Path: "/a", Url: "https://example.com/a"},
+		&PathUrl{Path: "b", Url: "https://example.com/b"},
+	)
+	h, err := ConfigHandler(data, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"/a": "https://example.com/a",
+		"/b": "https://example.com/b",
+	} {
+		if got := get(h, path).Header().Get("Location"); got != want {
+			t.Errorf("%s: got Location %q, want %q", path, got, want)
+		}
+	}
+	if rec := get(h, "/other"); rec.Code != http.StatusNotFound {
+		t.Errorf("/other: got code %d, want fallback", rec.Code)
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	got, err := ParseConfig(marshalConfig(t, &PathUrl{Path: "gh", Url: "https://github.com"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"/gh": "https://github.com"}; !maps.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, err := ParseConfig(nil); err != nil || len(got) != 0 {
+		t.Errorf("empty message: got %v, %v", got, err)
+	}
+}
+
+func TestConfigHandlerInvalid(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"corrupt":   {0xff, 0xff, 0xff},
+		"empty url": marshalConfig(t, &PathUrl{Path: "/a"}),
+	} {
+		if _, err := ConfigHandler(data, http.NotFoundHandler()); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
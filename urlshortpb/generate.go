@@ -0,0 +1,6 @@
+// Package urlshortpb contains the protocol buffer and gRPC
+// definitions used by package urlshort, and loads redirects
+// from binary Config messages.
+package urlshortpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative urlshort.proto
@@ -0,0 +1,278 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: urlshort.proto
+
+package urlshortpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ResolveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResolveRequest) Reset() {
+	*x = ResolveRequest{}
+	mi := &file_urlshort_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolveRequest) ProtoMessage() {}
+
+func (x *ResolveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_urlshort_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolveRequest.ProtoReflect.Descriptor instead.
+func (*ResolveRequest) Descriptor() ([]byte, []int) {
+	return file_urlshort_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ResolveRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type ResolveResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Url           string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResolveResponse) Reset() {
+	*x = ResolveResponse{}
+	mi := &file_urlshort_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolveResponse) ProtoMessage() {}
+
+func (x *ResolveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_urlshort_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolveResponse.ProtoReflect.Descriptor instead.
+func (*ResolveResponse) Descriptor() ([]byte, []int) {
+	return file_urlshort_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ResolveResponse) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+// Config is a set of redirects, as loaded by ConfigHandler.
+type Config struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Paths         []*PathUrl             `protobuf:"bytes,1,rep,name=paths,proto3" json:"paths,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Config) Reset() {
+	*x = Config{}
+	mi := &file_urlshort_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Config) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Config) ProtoMessage() {}
+
+func (x *Config) ProtoReflect() protoreflect.Message {
+	mi := &file_urlshort_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Config.ProtoReflect.Descriptor instead.
+func (*Config) Descriptor() ([]byte, []int) {
+	return file_urlshort_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Config) GetPaths() []*PathUrl {
+	if x != nil {
+		return x.Paths
+	}
+	return nil
+}
+
+type PathUrl struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PathUrl) Reset() {
+	*x = PathUrl{}
+	mi := &file_urlshort_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PathUrl) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PathUrl) ProtoMessage() {}
+
+func (x *PathUrl) ProtoReflect() protoreflect.Message {
+	mi := &file_urlshort_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PathUrl.ProtoReflect.Descriptor instead.
+func (*PathUrl) Descriptor() ([]byte, []int) {
+	return file_urlshort_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PathUrl) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *PathUrl) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+var File_urlshort_proto protoreflect.FileDescriptor
+
+const file_urlshort_proto_rawDesc = "" +
+	"\n" +
+	"\x0eurlshort.proto\x12\burlshort\"$\n" +
+	"\x0eResolveRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\"#\n" +
+	"\x0fResolveResponse\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\"1\n" +
+	"\x06Config\x12'\n" +
+	"\x05paths\x18\x01 \x03(\v2\x11.urlshort.PathUrlR\x05paths\"/\n" +
+	"\aPathUrl\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url2J\n" +
+	"\bResolver\x12>\n" +
+	"\aResolve\x12\x18.urlshort.ResolveRequest\x1a\x19.urlshort.ResolveResponseB*Z(github.com/kapeluszk/urlshort/urlshortpbb\x06proto3"
+
+var (
+	file_urlshort_proto_rawDescOnce sync.Once
+	file_urlshort_proto_rawDescData []byte
+)
+
+func file_urlshort_proto_rawDescGZIP() []byte {
+	file_urlshort_proto_rawDescOnce.Do(func() {
+		file_urlshort_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_urlshort_proto_rawDesc), len(file_urlshort_proto_rawDesc)))
+	})
+	return file_urlshort_proto_rawDescData
+}
+
+var file_urlshort_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_urlshort_proto_goTypes = []any{
+	(*ResolveRequest)(nil),  // 0: urlshort.ResolveRequest
+	(*ResolveResponse)(nil), // 1: urlshort.ResolveResponse
+	(*Config)(nil),          // 2: urlshort.Config
+	(*PathUrl)(nil),         // 3: urlshort.PathUrl
+}
+var file_urlshort_proto_depIdxs = []int32{
+	3, // 0: urlshort.Config.paths:type_name -> urlshort.PathUrl
+	0, // 1: urlshort.Resolver.Resolve:input_type -> urlshort.ResolveRequest
+	1, // 2: urlshort.Resolver.Resolve:output_type -> urlshort.ResolveResponse
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_urlshort_proto_init() }
+func file_urlshort_proto_init() {
+	if File_urlshort_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_urlshort_proto_rawDesc), len(file_urlshort_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_urlshort_proto_goTypes,
+		DependencyIndexes: file_urlshort_proto_depIdxs,
+		MessageInfos:      file_urlshort_proto_msgTypes,
+	}.Build()
+	File_urlshort_proto = out.File
+	file_urlshort_proto_goTypes = nil
+	file_urlshort_proto_depIdxs = nil
+}
@@ -0,0 +1,65 @@
+// Package urlshortprom exports Prometheus metrics for the
+// handlers of package urlshort.
+package urlshortprom
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/kapeluszk/urlshort"
+)
+
+// Metrics is a prometheus.Collector counting the redirects and
+// misses of the handlers it wraps. It exports
+// urlshort_redirects_total, labeled by status code, and
+// urlshort_misses_total.
+type Metrics struct {
+	redirects *prometheus.CounterVec
+	misses    prometheus.Counter
+}
+
+// NewMetrics returns a new Metrics registered with reg. Each
+// instance needs its own Registerer, as registering two of
+// them with the same one fails.
+func NewMetrics(reg prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		redirects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "urlshort_redirects_total",
+			Help: "Number of requests answered with a redirect, by status code.",
+		}, []string{"code"}),
+		misses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "urlshort_misses_total",
+			Help: "Number of requests that were not redirected.",
+		}),
+	}
+	if err := reg.Register(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.redirects.Describe(ch)
+	m.misses.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.redirects.Collect(ch)
+	m.misses.Collect(ch)
+}
+
+// Wrap returns an http.Handler that calls h and counts its
+// response in m.
+func (m *Metrics) Wrap(h http.Handler) http.Handler {
+	return urlshort.ObserveHandler(func(r *http.Request, status int, location string) {
+		if status >= 300 && status <= 399 && location != "" {
+			m.redirects.WithLabelValues(strconv.Itoa(status)).Inc()
+		} else {
+			m.misses.Inc()
+		}
+	}, h)
+}
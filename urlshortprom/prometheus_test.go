@@ -0,0 +1,56 @@
+package urlshortprom
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/kapeluszk/urlshort"
+)
+
+func get(h http.Handler, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := NewMetrics(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := m.Wrap(urlshort.MapHandler(map[string]string{"/a": "https://example.com/a"}, http.NotFoundHandler()))
+	get(h, "/a")
+	get(h, "/a")
+	get(h, "/b")
+	p := m.Wrap(urlshort.MapHandlerWithStatus(map[string]string{"/p": "https://example.com/p"}, http.StatusMovedPermanently, http.NotFoundHandler()))
+	get(p, "/p")
+
+	want := `
+# HELP urlshort_misses_total Number of requests that were not redirected.
+# TYPE urlshort_misses_total counter
+urlshort_misses_total 1
+# HELP urlshort_redirects_total Number of requests answered with a redirect, by status code.
+# TYPE urlshort_redirects_total counter
+urlshort_redirects_total{code="301"} 1
+urlshort_redirects_total{code="302"} 2
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNewMetricsRegisteredTwice(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	if _, err := NewMetrics(reg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewMetrics(reg); err == nil {
+		t.Errorf("second NewMetrics with the same Registerer: no error")
+	}
+}
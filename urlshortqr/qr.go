@@ -0,0 +1,62 @@
+// Package urlshortqr serves QR codes for short links.
+package urlshortqr
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// Sizes, in pixels, of the images served by Handler.
+const (
+	defaultSize = 256
+	minSize     = 64
+	maxSize     = 1024
+)
+
+// Handler returns an http.Handler that serves a PNG image of a
+// QR code encoding baseURL followed by the request path, such
+// as "https://go.acme.com/github" for "/github" when baseURL
+// is "https://go.acme.com". It is meant to be mounted under a
+// prefix with urlshort.StripPrefix, so that "/qr/github"
+// serves the code for "/github".
+//
+// The size query parameter sets the width and height of the
+// image in pixels, from 64 to 1024; it defaults to 256.
+// Requests without a path or with an invalid size get
+// http.StatusBadRequest.
+func Handler(baseURL string) http.Handler {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if strings.Trim(path, "/") == "" {
+			http.Error(w, "missing path", http.StatusBadRequest)
+			return
+		}
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+
+		size := defaultSize
+		if s := r.URL.Query().Get("size"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < minSize || n > maxSize {
+				http.Error(w, "size must be a number of pixels from 64 to 1024", http.StatusBadRequest)
+				return
+			}
+			size = n
+		}
+
+		png, err := qrcode.Encode(baseURL+path, qrcode.Medium, size)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Content-Length", strconv.Itoa(len(png)))
+		w.Write(png)
+	})
+}
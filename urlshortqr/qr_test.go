@@ -0,0 +1,63 @@
+package urlshortqr
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/skip2/go-qrcode"
+
+	"github.com/kapeluszk/urlshort"
+)
+
+func get(h http.Handler, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func TestHandler(t *testing.T) {
+	h := urlshort.StripPrefix("/qr", Handler("https://go.acme.com/").ServeHTTP)
+
+	rec := get(h, "/qr/github")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got code %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("got Content-Type %q, want image/png", ct)
+	}
+	want, err := qrcode.Encode("https://go.acme.com/github", qrcode.Medium, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), want) {
+		t.Errorf("image does not encode https://go.acme.com/github")
+	}
+}
+
+func TestHandlerSize(t *testing.T) {
+	h := Handler("https://go.acme.com")
+	for _, size := range []int{64, 100, 1024} {
+		rec := get(h, "/github?size="+strconv.Itoa(size))
+		img, err := png.Decode(rec.Body)
+		if err != nil {
+			t.Errorf("size %d: %v", size, err)
+			continue
+		}
+		if b := img.Bounds(); b.Dx() != size || b.Dy() != size {
+			t.Errorf("size %d: got %dx%d image", size, b.Dx(), b.Dy())
+		}
+	}
+}
+
+func TestHandlerBadRequest(t *testing.T) {
+	h := Handler("https://go.acme.com")
+	for _, target := range []string{"/", "/a?size=63", "/a?size=1025", "/a?size=big"} {
+		if rec := get(h, target); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: got code %d, want 400", target, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Package urlshortredis serves redirects looked up in Redis.
+package urlshortredis
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/kapeluszk/urlshort"
+)
+
+// Handler will return an http.HandlerFunc (which also
+// implements http.Handler) that looks up the destination for
+// each request in Redis under the key keyPrefix+path. If the
+// key does not exist, then the fallback http.Handler will be
+// called instead.
+//
+// Unlike the handlers of package urlshort, Redis is queried on
+// every request, so each redirect pays the latency of a round
+// trip to Redis. The lookup uses the request context, so it is
+// abandoned when the client goes away; see HandlerWithTimeout
+// to bound it further. Redis errors are treated like a missing
+// key; see Options.ErrorHandler to handle them differently.
+func Handler(client *redis.Client, keyPrefix string, fallback http.Handler) http.HandlerFunc {
+	return HandlerWithOptions(client, keyPrefix, Options{}, fallback)
+}
+
+// HandlerWithTimeout works like Handler, but gives up on a
+// lookup after timeout and calls the fallback. A timeout of
+// zero or less means no timeout. The client must be created
+// with ContextTimeoutEnabled set for the timeout to interrupt
+// a lookup that is waiting on Redis.
+func HandlerWithTimeout(client *redis.Client, keyPrefix string, timeout time.Duration, fallback http.Handler) http.HandlerFunc {
+	return HandlerWithOptions(client, keyPrefix, Options{Timeout: timeout}, fallback)
+}
+
+// Options configures HandlerWithOptions.
+type Options struct {
+	// Timeout bounds each lookup, as in HandlerWithTimeout.
+	// Zero or less means no timeout.
+	Timeout time.Duration
+
+	// ErrorHandler, if set, is called instead of the fallback
+	// when a lookup fails for any reason other than a missing
+	// key, including a timeout.
+	ErrorHandler urlshort.ErrorHandler
+}
+
+// HandlerWithOptions works like Handler, configured by opts.
+func HandlerWithOptions(client *redis.Client, keyPrefix string, opts Options, fallback http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if opts.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+			defer cancel()
+		}
+
+		dest, err := client.Get(ctx, keyPrefix+r.URL.Path).Result()
+		if err == nil && dest != "" {
+			http.Redirect(w, r, dest, http.StatusFound)
+			return
+		}
+		if err != nil && !errors.Is(err, redis.Nil) && opts.ErrorHandler != nil {
+			opts.ErrorHandler(w, r, err)
+			return
+		}
+
+		fallback.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,113 @@
+package urlshortredis
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func get(h http.Handler, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func newTestClient(t *testing.T, addr string) *redis.Client {
+	t.Helper()
+	c := redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1, ContextTimeoutEnabled: true})
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestHandler(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.Set("short:/a", "https://example.com/a")
+	h := Handler(newTestClient(t, mr.Addr()), "short:", http.NotFoundHandler())
+
+	rec := get(h, "/a")
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "https://example.com/a" {
+		t.Errorf("/a: got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if rec := get(h, "/b"); rec.Code != http.StatusNotFound {
+		t.Errorf("/b: got code %d, want fallback", rec.Code)
+	}
+
+	// Lookups happen per request, so changes are live.
+	mr.Set("short:/b", "https://example.com/b")
+	if rec := get(h, "/b"); rec.Code != http.StatusFound {
+		t.Errorf("/b after Set: got code %d, want 302", rec.Code)
+	}
+}
+
+func TestHandlerRedisDown(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.Set("short:/a", "https://example.com/a")
+	h := Handler(newTestClient(t, mr.Addr()), "short:", http.NotFoundHandler())
+
+	mr.Close()
+	if rec := get(h, "/a"); rec.Code != http.StatusNotFound {
+		t.Errorf("got code %d, want fallback", rec.Code)
+	}
+}
+
+func TestHandlerWithTimeout(t *testing.T) {
+	// A server that accepts connections but never answers.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	h := HandlerWithTimeout(newTestClient(t, ln.Addr().String()), "short:", 50*time.Millisecond, http.NotFoundHandler())
+	start := time.Now()
+	if rec := get(h, "/a"); rec.Code != http.StatusNotFound {
+		t.Errorf("got code %d, want fallback", rec.Code)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("lookup took %v despite the timeout", elapsed)
+	}
+}
+
+func TestHandlerWithOptionsErrorHandler(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.Set("short:/a", "https://example.com/a")
+	var lookupErr error
+	h := HandlerWithOptions(newTestClient(t, mr.Addr()), "short:", Options{
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			lookupErr = err
+			http.Error(w, "lookup failed", http.StatusServiceUnavailable)
+		},
+	}, http.NotFoundHandler())
+
+	if rec := get(h, "/a"); rec.Code != http.StatusFound {
+		t.Errorf("/a: got code %d, want 302", rec.Code)
+	}
+	if rec := get(h, "/b"); rec.Code != http.StatusNotFound {
+		t.Errorf("/b: got code %d, want fallback for a missing key", rec.Code)
+	}
+	if lookupErr != nil {
+		t.Errorf("ErrorHandler called with %v while redis was up", lookupErr)
+	}
+
+	mr.Close()
+	if rec := get(h, "/a"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("redis down: got code %d, want the ErrorHandler's", rec.Code)
+	}
+	if lookupErr == nil {
+		t.Errorf("redis down: ErrorHandler not called")
+	}
+}
@@ -0,0 +1,244 @@
+package urlshort
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// ValidatedMapHandler works like MapHandler, but first checks
+// every destination with ValidateURLs and returns its error
+// instead of a handler if any of them is invalid.
+func ValidatedMapHandler(pathsToUrls map[string]string, fallback http.Handler) (http.HandlerFunc, error) {
+	if err := ValidateURLs(pathsToUrls); err != nil {
+		return nil, err
+	}
+
+	return MapHandler(pathsToUrls, fallback), nil
+}
+
+// ValidateURLs checks that every destination in pathsToUrls is
+// an absolute http or https URL with a host. The returned
+// error lists every invalid entry, ordered by path, and is nil
+// if all of them are valid.
+func ValidateURLs(pathsToUrls map[string]string) error {
+	var errs []error
+	for _, path := range sortedKeys(pathsToUrls) {
+		if err := validateURL(pathsToUrls[path]); err != nil {
+			errs = append(errs, fmt.Errorf("urlshort: %s: %w", path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validateURL(dest string) error {
+	u, err := url.ParseRequestURI(dest)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid url %q: scheme must be http or https", dest)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid url %q: missing host", dest)
+	}
+	return nil
+}
+
+// ValidateHosts checks that every destination in pathsToUrls
+// points at one of the allowed hosts, to keep a shortener from
+// being used as an open redirect. An allowed host matches only
+// itself, unless written as "*.example.com", which matches any
+// subdomain of example.com but not example.com itself.
+// Relative destinations, which stay on the same host, are
+// always allowed.
+//
+// The returned error lists every disallowed entry, ordered by
+// path, and is nil if all of them are allowed.
+func ValidateHosts(pathsToUrls map[string]string, allowed []string) error {
+	var errs []error
+	for _, path := range sortedKeys(pathsToUrls) {
+		dest := pathsToUrls[path]
+		if !hostAllowed(dest, allowed) {
+			errs = append(errs, fmt.Errorf("urlshort: %s: destination %q is not on an allowed host", path, dest))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// AllowedHostsHandler works like MapHandlerWithOptions with
+// Options.AllowedHosts set to allowed, but first checks the
+// static map with ValidateHosts and returns its error, listing
+// every disallowed destination, instead of a handler.
+func AllowedHostsHandler(pathsToUrls map[string]string, allowed []string, fallback http.Handler) (http.HandlerFunc, error) {
+	if err := ValidateHosts(pathsToUrls, allowed); err != nil {
+		return nil, err
+	}
+
+	return MapHandlerWithOptions(pathsToUrls, Options{AllowedHosts: allowed}, fallback), nil
+}
+
+// hostAllowed reports whether dest is relative or its host
+// matches allowed as described by ValidateHosts.
+func hostAllowed(dest string, allowed []string) bool {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return false
+	}
+	if u.Host == "" {
+		return u.Scheme == ""
+	}
+
+	return hostMatches(u.Hostname(), allowed)
+}
+
+// hostMatches reports whether host matches one of patterns as
+// described by ValidateHosts.
+func hostMatches(host string, patterns []string) bool {
+	host = strings.ToLower(host)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks pathsToUrls for common configuration
+// mistakes without building a handler, so it can be used as a
+// pre-deploy gate. It reports, ordered by path:
+//
+//   - paths that do not begin with a slash
+//   - paths that duplicate another once the missing slash is
+//     added, such as "github" and "/github"
+//   - destinations that are empty or cannot be parsed as URLs
+//   - relative destinations that redirect a path to itself,
+//     such as "/" mapped to "/"
+//
+// Every error names the offending path. The result is empty if
+// no problem was found.
+func Validate(pathsToUrls map[string]string) []error {
+	var errs []error
+	for _, path := range sortedKeys(pathsToUrls) {
+		dest := pathsToUrls[path]
+		if !strings.HasPrefix(path, "/") {
+			errs = append(errs, fmt.Errorf("urlshort: %s: path does not begin with a slash", path))
+			if _, ok := pathsToUrls[normalizePath(path)]; ok {
+				errs = append(errs, fmt.Errorf("urlshort: %s: duplicate of path %s", path, normalizePath(path)))
+			}
+		}
+		if strings.TrimSpace(dest) == "" {
+			errs = append(errs, fmt.Errorf("urlshort: %s: empty url", path))
+		} else if _, err := url.Parse(dest); err != nil {
+			errs = append(errs, fmt.Errorf("urlshort: %s: %w", path, err))
+		} else if redirectsToSelf(path, dest) {
+			errs = append(errs, fmt.Errorf("urlshort: %s: redirects to itself", path))
+		}
+	}
+	return errs
+}
+
+// CheckLoops returns an error describing the first cycle
+// found among the relative destinations of pathsToUrls, such
+// as "/a" to "/b" and "/b" back to "/a", which a client would
+// follow forever. Relative destinations are resolved against
+// their path and followed only when they name another path of
+// the map exactly; query strings and absolute URLs are
+// ignored.
+func CheckLoops(pathsToUrls map[string]string) error {
+	next := func(path string) (string, bool) {
+		u, err := url.Parse(pathsToUrls[path])
+		if err != nil || u.Scheme != "" || u.Host != "" {
+			return "", false
+		}
+		target := (&url.URL{Path: path}).ResolveReference(u).Path
+		_, ok := pathsToUrls[target]
+		return target, ok
+	}
+
+	done := make(map[string]bool, len(pathsToUrls))
+	for _, start := range sortedKeys(pathsToUrls) {
+		var chain []string
+		for path, ok := start, true; ok && !done[path]; path, ok = next(path) {
+			if i := slices.Index(chain, path); i >= 0 {
+				loop := append(chain[i:], path)
+				return fmt.Errorf("urlshort: redirect loop: %s", strings.Join(loop, " -> "))
+			}
+			chain = append(chain, path)
+		}
+		for _, path := range chain {
+			done[path] = true
+		}
+	}
+	return nil
+}
+
+// maxUploadSize bounds the body read by ValidateUploadHandler.
+const maxUploadSize = 10 << 20
+
+// ValidateUploadHandler returns an http.Handler that validates
+// a config file POSTed as the request body without applying
+// it. format is "yaml" (or "yml") or "json". The file is
+// parsed as by YAMLHandler or JSONHandler with duplicate paths
+// disallowed, and the result checked with Validate. The
+// response is a JSON report:
+//
+//	{"ok": false, "problems": ["urlshort: /a: empty url"]}
+//
+// Bodies over 10 MiB get http.StatusRequestEntityTooLarge and
+// methods other than POST get http.StatusMethodNotAllowed.
+func ValidateUploadHandler(format string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxUploadSize))
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				writeJSONError(w, http.StatusRequestEntityTooLarge, "body too large")
+				return
+			}
+			writeJSONError(w, http.StatusBadRequest, "reading body: "+err.Error())
+			return
+		}
+
+		problems := []string{}
+		for _, err := range validateUpload(format, data) {
+			problems = append(problems, err.Error())
+		}
+		writeJSON(w, http.StatusOK, struct {
+			Ok       bool     `json:"ok"`
+			Problems []string `json:"problems"`
+		}{len(problems) == 0, problems})
+	})
+}
+
+func validateUpload(format string, data []byte) []error {
+	opts := ParseOptions{DisallowDuplicates: true}
+	var pathsToUrls map[string]string
+	var err error
+	switch strings.ToLower(format) {
+	case "yaml", "yml":
+		pathsToUrls, err = ParseYAMLWithOptions(data, opts)
+	case "json":
+		pathsToUrls, err = ParseJSONWithOptions(data, opts)
+	default:
+		err = fmt.Errorf("urlshort: unknown format %q", format)
+	}
+	if err != nil {
+		return []error{err}
+	}
+	return Validate(pathsToUrls)
+}
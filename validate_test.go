@@ -0,0 +1,269 @@
+package urlshort
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidatedMapHandler(t *testing.T) {
+	h, err := ValidatedMapHandler(map[string]string{
+		"/a": "https://example.com/a",
+		"/b": "http://example.com:8080/b?x=1",
+	}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := get(h, "/b"); rec.Code != http.StatusFound {
+		t.Errorf("/b: got code %d, want 302", rec.Code)
+	}
+}
+
+func TestValidateURLs(t *testing.T) {
+	err := ValidateURLs(map[string]string{
+		"/ok":       "https://example.com/",
+		"/scheme":   "htps://example.com",
+		"/relative": "example.com",
+		"/nohost":   "https://",
+		"/ftp":      "ftp://example.com/file",
+	})
+	if err == nil {
+		t.Fatal("invalid urls accepted")
+	}
+	msg := err.Error()
+	for _, path := range []string{"/scheme:", "/relative:", "/nohost:", "/ftp:"} {
+		if !strings.Contains(msg, path) {
+			t.Errorf("error does not mention %s:\n%s", path, msg)
+		}
+	}
+	if strings.Contains(msg, "/ok") {
+		t.Errorf("error mentions the valid entry:\n%s", msg)
+	}
+	if strings.Index(msg, "/ftp:") > strings.Index(msg, "/scheme:") {
+		t.Errorf("entries are not ordered by path:\n%s", msg)
+	}
+
+	if err := ValidateURLs(map[string]string{"/ok": "https://example.com/"}); err != nil {
+		t.Errorf("valid map: %v", err)
+	}
+}
+
+func TestValidateHosts(t *testing.T) {
+	err := ValidateHosts(map[string]string{
+		"/a": "https://example.com/a",
+		"/b": "https://evil.example/b",
+		"/c": "https://docs.example.com/c",
+		"/d": "/local",
+		"/e": "//evil.example/e",
+	}, []string{"example.com"})
+	if err == nil {
+		t.Fatal("disallowed hosts accepted")
+	}
+	msg := err.Error()
+	for _, path := range []string{"/b:", "/c:", "/e:"} {
+		if !strings.Contains(msg, path) {
+			t.Errorf("error does not mention %s:\n%s", path, msg)
+		}
+	}
+	for _, path := range []string{"/a:", "/d:"} {
+		if strings.Contains(msg, path) {
+			t.Errorf("error mentions the allowed entry %s:\n%s", path, msg)
+		}
+	}
+
+	tests := []struct {
+		dest  string
+		allow string
+		ok    bool
+	}{
+		{"https://docs.example.com/", "*.example.com", true},
+		{"https://a.b.example.com/", "*.example.com", true},
+		{"https://example.com/", "*.example.com", false},
+		{"https://notexample.com/", "*.example.com", false},
+		{"https://EXAMPLE.com/", "example.com", true},
+		{"https://example.com:8443/", "example.com", true},
+	}
+	for _, tt := range tests {
+		err := ValidateHosts(map[string]string{"/a": tt.dest}, []string{tt.allow})
+		if (err == nil) != tt.ok {
+			t.Errorf("%s with %s: got error %v, want allowed %v", tt.dest, tt.allow, err, tt.ok)
+		}
+	}
+}
+
+func TestAllowedHosts(t *testing.T) {
+	h := MapHandlerWithOptions(map[string]string{
+		"/a": "https://example.com/a",
+		"/b": "https://evil.example/b",
+		"/c": "/local",
+	}, Options{AllowedHosts: []string{"example.com"}}, http.NotFoundHandler())
+	for path, code := range map[string]int{
+		"/a": http.StatusFound,
+		"/b": http.StatusNotFound,
+		"/c": http.StatusFound,
+	} {
+		if rec := get(h, path); rec.Code != code {
+			t.Errorf("%s: got code %d, want %d", path, rec.Code, code)
+		}
+	}
+
+	h = MapHandlerWithOptions(map[string]string{"/a": "https://example.com/a"}, Options{
+		AllowedHosts: []string{"example.com"},
+		BeforeRedirect: func(r *http.Request, dest string) (string, bool) {
+			return "https://evil.example/", true
+		},
+	}, http.NotFoundHandler())
+	if rec := get(h, "/a"); rec.Code != http.StatusNotFound {
+		t.Errorf("rewritten by BeforeRedirect: got code %d, want fallback", rec.Code)
+	}
+}
+
+func TestAllowedHostsHandler(t *testing.T) {
+	_, err := AllowedHostsHandler(map[string]string{
+		"/a": "https://evil.example/a",
+		"/b": "https://example.com/b",
+	}, []string{"example.com"}, http.NotFoundHandler())
+	if err == nil || !strings.Contains(err.Error(), "/a:") {
+		t.Errorf("got error %v, want one naming /a", err)
+	}
+
+	h, err := AllowedHostsHandler(map[string]string{
+		"/a": "https://docs.example.com/a",
+		"/b": "/local",
+	}, []string{"*.example.com"}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := get(h, "/a"); rec.Code != http.StatusFound {
+		t.Errorf("/a: got code %d, want 302", rec.Code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	errs := Validate(map[string]string{
+		"gh":   "https://example.com/gh",
+		"/gh":  "https://example.com/gh",
+		"docs": "https://example.com/docs",
+		"/e":   " ",
+		"/bad": "http://a b.example/",
+		"/":    "/",
+		"/ok":  "/elsewhere",
+	})
+	want := []string{
+		"urlshort: /: redirects to itself",
+		"/bad:",
+		"urlshort: /e: empty url",
+		"urlshort: docs: path does not begin with a slash",
+		"urlshort: gh: path does not begin with a slash",
+		"urlshort: gh: duplicate of path /gh",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(errs), len(want), errs)
+	}
+	for i, err := range errs {
+		if !strings.Contains(err.Error(), want[i]) {
+			t.Errorf("error %d: got %q, want it to contain %q", i, err, want[i])
+		}
+	}
+
+	if errs := Validate(map[string]string{"/a": "https://example.com/a"}); len(errs) != 0 {
+		t.Errorf("valid map: got %v", errs)
+	}
+}
+
+// uploadReport is the JSON report of ValidateUploadHandler.
+type uploadReport struct {
+	Ok       bool     `json:"ok"`
+	Problems []string `json:"problems"`
+}
+
+func postUpload(t *testing.T, format, method, body string) (int, uploadReport) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	ValidateUploadHandler(format).ServeHTTP(rec, httptest.NewRequest(method, "/validate", strings.NewReader(body)))
+	var report uploadReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("%s: %v: %s", format, err, rec.Body)
+	}
+	return rec.Code, report
+}
+
+func TestValidateUploadHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		body     string
+		problems []string
+	}{
+		{"valid yaml", "yaml", "- path: /a\n  url: https://example.com/a\n", nil},
+		{"valid json", "JSON", `[{"path": "/a", "url": "https://example.com/a"}]`, nil},
+		{"duplicate", "json", `[{"path": "/a", "url": "https://example.com/1"}, {"path": "a", "url": "https://example.com/2"}]`, []string{`"/a"`}},
+		{"empty url", "yml", "- path: /a\n  url: \"\"\n", []string{"empty url"}},
+		{"bad url", "yaml", "- path: /a\n  url: \"http://a b.example/\"\n", []string{"/a:"}},
+		{"syntax error", "json", `[{"path": `, []string{"urlshort"}},
+		{"unknown format", "toml", "", []string{"unknown format"}},
+	}
+	for _, tt := range tests {
+		code, report := postUpload(t, tt.format, http.MethodPost, tt.body)
+		if code != http.StatusOK {
+			t.Errorf("%s: got code %d, want 200", tt.name, code)
+		}
+		if report.Ok != (len(tt.problems) == 0) || len(report.Problems) != len(tt.problems) {
+			t.Errorf("%s: got report %+v, want problems containing %q", tt.name, report, tt.problems)
+			continue
+		}
+		for i, want := range tt.problems {
+			if !strings.Contains(report.Problems[i], want) {
+				t.Errorf("%s: got problem %q, want one containing %q", tt.name, report.Problems[i], want)
+			}
+		}
+	}
+}
+
+func TestValidateUploadHandlerRejected(t *testing.T) {
+	if code, _ := postUpload(t, "yaml", http.MethodGet, ""); code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: got code %d, want 405", code)
+	}
+	if code, _ := postUpload(t, "yaml", http.MethodPost, strings.Repeat("#", maxUploadSize+1)); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized body: got code %d, want 413", code)
+	}
+}
+
+func TestCheckLoops(t *testing.T) {
+	tests := []struct {
+		m    map[string]string
+		want string
+	}{
+		{map[string]string{"/a": "/b", "/b": "/a"}, "urlshort: redirect loop: /a -> /b -> /a"},
+		{map[string]string{"/x": "/a", "/a": "b", "/b": "/c?q=1", "/c": "/a"}, "urlshort: redirect loop: /a -> /b -> /c -> /a"},
+		{map[string]string{"/a": "/a"}, "urlshort: redirect loop: /a -> /a"},
+		{map[string]string{"/a": "/b", "/b": "/c", "/c": "https://example.com/"}, ""},
+		{map[string]string{"/a": "https://example.com/b", "/b": "/a"}, ""},
+		{map[string]string{"/a": "/missing"}, ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if err := CheckLoops(tt.m); err != nil {
+			got = err.Error()
+		}
+		if got != tt.want {
+			t.Errorf("%v: got error %q, want %q", tt.m, got, tt.want)
+		}
+	}
+}
+
+func TestParseOptionsDisallowLoops(t *testing.T) {
+	jsn := []byte(`{"/a": "/b", "/b": "/a"}`)
+	if _, err := ParseJSONWithOptions(jsn, ParseOptions{DisallowLoops: true}); err == nil {
+		t.Errorf("JSON: loop accepted with DisallowLoops")
+	}
+	if _, err := ParseJSONWithOptions(jsn, ParseOptions{}); err != nil {
+		t.Errorf("JSON: loop rejected without DisallowLoops: %v", err)
+	}
+	yml := []byte("- path: /a\n  url: /b\n- path: /b\n  url: /a\n")
+	if _, err := ParseYAMLWithOptions(yml, ParseOptions{DisallowLoops: true}); err == nil {
+		t.Errorf("YAML: loop accepted with DisallowLoops")
+	}
+}